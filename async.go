@@ -0,0 +1,189 @@
+package demo
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned by the Write method of an AsyncAggregatedWriter
+// with the QueueError policy when its queue is full.
+var ErrQueueFull = errors.New("demo: queue full")
+
+// QueueFullPolicy determines what an AsyncAggregatedWriter does with a write
+// when its queue is full.
+type QueueFullPolicy int
+
+const (
+	// QueueBlock blocks the write until there is room in the queue.
+	QueueBlock QueueFullPolicy = iota
+
+	// QueueDropOldest discards the oldest queued write to make room.
+	QueueDropOldest
+
+	// QueueDropNewest discards the write.
+	QueueDropNewest
+
+	// QueueError fails the write with ErrQueueFull, without storing the
+	// error.
+	QueueError
+)
+
+// AsyncOption configures an AsyncAggregatedWriter.
+type AsyncOption func(*AsyncAggregatedWriter)
+
+// WithQueueFullPolicy configures what happens to a write when the queue is
+// full. The default is QueueBlock. Bytes discarded by QueueDropOldest or
+// QueueDropNewest are counted by Dropped, and writes that discard bytes still
+// report success.
+func WithQueueFullPolicy(policy QueueFullPolicy) AsyncOption {
+	return func(a *AsyncAggregatedWriter) { a.policy = policy }
+}
+
+// AsyncAggregatedWriter queues writes for a background goroutine to write to
+// the underlying writer, so that writers only block when the queue is full.
+// Errors from the underlying writer are reported by later calls to Write,
+// and by Err and Result. It is safe for concurrent use.
+type AsyncAggregatedWriter struct {
+	dropped int64 // accessed atomically
+
+	mu sync.Mutex // guards ag
+	ag *AggregatedWriter
+
+	errMu sync.Mutex // guards err
+	err   error      // the first error from ag, checked by Write
+
+	qmu    sync.RWMutex // guards closed and sends to queue
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+	policy QueueFullPolicy
+}
+
+// NewAsyncAggregatedWriter returns an AsyncAggregatedWriter that queues up
+// to queueSize writes to w. Close must be called to release its goroutine.
+func NewAsyncAggregatedWriter(w io.Writer, queueSize int, opts ...AsyncOption) *AsyncAggregatedWriter {
+	a := &AsyncAggregatedWriter{
+		ag:    &AggregatedWriter{w: w},
+		queue: make(chan []byte, queueSize),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncAggregatedWriter) run() {
+	defer close(a.done)
+	for p := range a.queue {
+		a.mu.Lock()
+		_, err := a.ag.Write(p)
+		a.mu.Unlock()
+		if err != nil {
+			a.errMu.Lock()
+			if a.err == nil {
+				a.err = err
+			}
+			a.errMu.Unlock()
+		}
+	}
+}
+
+// Write queues a copy of p to be written and returns len(p), unless an
+// earlier write has failed or a is closed. If the queue is full, the
+// configured QueueFullPolicy applies.
+func (a *AsyncAggregatedWriter) Write(p []byte) (n int, err error) {
+	a.qmu.RLock()
+	defer a.qmu.RUnlock()
+	if a.closed {
+		return 0, io.ErrClosedPipe
+	}
+	a.errMu.Lock()
+	err = a.err
+	a.errMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	if err := a.enqueue(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// enqueue queues b according to the queue full policy.
+func (a *AsyncAggregatedWriter) enqueue(b []byte) error {
+	switch a.policy {
+	case QueueDropOldest:
+		for {
+			select {
+			case a.queue <- b:
+				return nil
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				atomic.AddInt64(&a.dropped, int64(len(old)))
+			default:
+			}
+		}
+	case QueueDropNewest:
+		select {
+		case a.queue <- b:
+		default:
+			atomic.AddInt64(&a.dropped, int64(len(b)))
+		}
+	case QueueError:
+		select {
+		case a.queue <- b:
+		default:
+			return ErrQueueFull
+		}
+	default:
+		a.queue <- b
+	}
+	return nil
+}
+
+// Dropped returns the number of bytes discarded because the queue was full.
+func (a *AsyncAggregatedWriter) Dropped() int64 { return atomic.LoadInt64(&a.dropped) }
+
+// Close waits for all queued writes to complete and then closes the
+// underlying writer if it implements io.Closer.
+func (a *AsyncAggregatedWriter) Close() error {
+	a.qmu.Lock()
+	if a.closed {
+		a.qmu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.qmu.Unlock()
+
+	<-a.done
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ag.Close()
+}
+
+func (a *AsyncAggregatedWriter) N() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ag.N()
+}
+
+func (a *AsyncAggregatedWriter) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ag.Err()
+}
+
+func (a *AsyncAggregatedWriter) Result() (n int64, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ag.Result()
+}
@@ -0,0 +1,136 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncAggregatedWriter(t *testing.T) {
+	b := &slowWriter{delay: time.Millisecond}
+	w := NewAsyncAggregatedWriter(b, 4)
+	var expect strings.Builder
+	for i := 0; i < 32; i++ {
+		fmt.Fprintf(w, "%d,", i)
+		fmt.Fprintf(&expect, "%d,", i)
+	}
+	fatalOn(t, w.Close())
+
+	select {
+	case <-w.done:
+	default:
+		t.Errorf("expected worker to exit on close")
+	}
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, int64(expect.Len()), n)
+	assertString(t, expect.String(), b.buf.String())
+
+	if _, err := fmt.Fprint(w, "foo"); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	fatalOn(t, w.Close())
+}
+
+func TestAsyncAggregatedWriterError(t *testing.T) {
+	expect := errors.New("broken")
+	w := NewAsyncAggregatedWriter(&errWriter{n: 3, err: expect}, 1)
+	fmt.Fprint(w, "foobar")
+	w.Close()
+	n, err := w.Result()
+	assertInt64(t, 3, n)
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+}
+
+func TestAsyncAggregatedWriterCopiesInput(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAsyncAggregatedWriter(b, 1)
+	p := []byte("foo")
+	w.Write(p)
+	copy(p, "bar")
+	fatalOn(t, w.Close())
+	assertString(t, "foo", b.String())
+}
+
+// gateWriter blocks each write until release is closed, signalling started
+// when the first write arrives.
+type gateWriter struct {
+	bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+// fillQueue writes "a", which the worker blocks on, and then "b", which
+// fills a queue of size 1.
+func fillQueue(t *testing.T, w *AsyncAggregatedWriter, g *gateWriter) {
+	_, err := w.Write([]byte("a"))
+	fatalOn(t, err)
+	<-g.started
+	_, err = w.Write([]byte("b"))
+	fatalOn(t, err)
+}
+
+func TestQueueFullPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy  QueueFullPolicy
+		err     error
+		output  string
+		dropped int64
+	}{
+		{QueueDropOldest, nil, "ac", 1},
+		{QueueDropNewest, nil, "ab", 1},
+		{QueueError, ErrQueueFull, "ab", 0},
+	} {
+		g := newGateWriter()
+		w := NewAsyncAggregatedWriter(g, 1, WithQueueFullPolicy(tt.policy))
+		fillQueue(t, w, g)
+		if _, err := w.Write([]byte("c")); err != tt.err {
+			t.Errorf("expected %v, got: %v", tt.err, err)
+		}
+		close(g.release)
+		fatalOn(t, w.Close())
+		assertString(t, tt.output, g.String())
+		assertInt64(t, tt.dropped, w.Dropped())
+		fatalOn(t, w.Err())
+	}
+}
+
+func TestQueueFullPolicyBlock(t *testing.T) {
+	g := newGateWriter()
+	w := NewAsyncAggregatedWriter(g, 1)
+	fillQueue(t, w, g)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Write([]byte("c"))
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expected write to block on a full queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(g.release)
+	<-done
+	fatalOn(t, w.Close())
+	assertString(t, "abc", g.String())
+	assertInt64(t, 0, w.Dropped())
+}
@@ -0,0 +1,95 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ErrCaptureMismatch is returned by VerifyCapture when the captured bytes do
+// not have the expected checksum.
+var ErrCaptureMismatch = errors.New("demo: captured bytes do not match checksum")
+
+// WithInMemoryCapture configures w to keep a copy of the first limit bytes
+// written to the underlying writer, available via Captured. Bytes beyond the
+// limit are still written and counted but not captured. A non-positive limit
+// captures everything.
+func WithInMemoryCapture(limit int) Option {
+	return func(w *AggregatedWriter) { w.capture = &capture{limit: limit} }
+}
+
+type capture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+	crc       uint32 // IEEE CRC-32 of the bytes captured
+}
+
+func (c *capture) write(p []byte) {
+	if c.limit > 0 {
+		if room := c.limit - c.buf.Len(); len(p) > room {
+			p = p[:room]
+			c.truncated = true
+		}
+	}
+	c.buf.Write(p)
+	c.crc = crc32.Update(c.crc, crc32.IEEETable, p)
+}
+
+// Captured returns the bytes captured by WithInMemoryCapture. The slice
+// aliases the capture buffer and is only valid until the next write.
+func (w *AggregatedWriter) Captured() []byte {
+	w.lock()
+	defer w.unlock()
+	if w.capture == nil {
+		return nil
+	}
+	return w.capture.buf.Bytes()
+}
+
+// CaptureTruncated reports whether any bytes were not captured because the
+// capture limit was reached.
+func (w *AggregatedWriter) CaptureTruncated() bool {
+	w.lock()
+	defer w.unlock()
+	return w.capture != nil && w.capture.truncated
+}
+
+// VerifyCapture checks that the bytes captured by WithInMemoryCapture have
+// the IEEE CRC-32 checksum expected, and that the capture buffer still holds
+// exactly the bytes that were captured. It returns an error wrapping
+// ErrCaptureMismatch if not.
+func (w *AggregatedWriter) VerifyCapture(expected uint32) error {
+	w.lock()
+	defer w.unlock()
+	var buffered, captured uint32
+	if w.capture != nil {
+		buffered = crc32.ChecksumIEEE(w.capture.buf.Bytes())
+		captured = w.capture.crc
+	}
+	if buffered != captured {
+		return fmt.Errorf("%w: capture buffer corrupted", ErrCaptureMismatch)
+	}
+	if buffered != expected {
+		return fmt.Errorf("%w: got %08x, expected %08x", ErrCaptureMismatch, buffered, expected)
+	}
+	return nil
+}
+
+// DrainCapture writes the bytes captured by WithInMemoryCapture to dst and
+// removes them from the capture buffer, making room for further capture.
+// Counters, including N, are not affected. If dst fails, the bytes not
+// written remain captured.
+func (w *AggregatedWriter) DrainCapture(dst io.Writer) (int64, error) {
+	w.lock()
+	defer w.unlock()
+	if w.capture == nil {
+		return 0, nil
+	}
+	c := w.capture
+	n, err := c.buf.WriteTo(dst)
+	c.crc = crc32.ChecksumIEEE(c.buf.Bytes())
+	return n, err
+}
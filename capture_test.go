@@ -0,0 +1,72 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"testing"
+)
+
+func TestInMemoryCapture(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithInMemoryCapture(64))
+	fmt.Fprint(w, testOutput)
+	assertInt64(t, testOutputLength, w.N())
+	assertString(t, testOutput, string(w.Captured()))
+	if w.CaptureTruncated() {
+		t.Errorf("expected capture to be complete")
+	}
+}
+
+func TestInMemoryCaptureTruncated(t *testing.T) {
+	w := NewAggregatedWriter(ioutil.Discard, WithInMemoryCapture(8))
+	fmt.Fprint(w, "foo")
+	fmt.Fprint(w, testOutput)
+	assertInt64(t, 3+testOutputLength, w.N())
+	assertString(t, "foo"+testOutput[:5], string(w.Captured()))
+	if !w.CaptureTruncated() {
+		t.Errorf("expected capture to be truncated")
+	}
+}
+
+func TestVerifyCapture(t *testing.T) {
+	input := bytes.Repeat([]byte(testOutput), 1<<12)
+	w := NewAggregatedWriter(ioutil.Discard, WithInMemoryCapture(0))
+	for p := input; len(p) > 0; p = p[1000:] {
+		if len(p) < 1000 {
+			w.Write(p)
+			break
+		}
+		w.Write(p[:1000])
+	}
+	expect := crc32.ChecksumIEEE(input)
+	fatalOn(t, w.VerifyCapture(expect))
+	if err := w.VerifyCapture(expect + 1); !errors.Is(err, ErrCaptureMismatch) {
+		t.Errorf("expected %v, got: %v", ErrCaptureMismatch, err)
+	}
+
+	w.Captured()[0] ^= 0xff
+	if err := w.VerifyCapture(expect); !errors.Is(err, ErrCaptureMismatch) {
+		t.Errorf("expected %v, got: %v", ErrCaptureMismatch, err)
+	}
+}
+
+func TestDrainCapture(t *testing.T) {
+	w := NewAggregatedWriter(ioutil.Discard, WithInMemoryCapture(32))
+	stringify(w, testInput)
+
+	dst := &bytes.Buffer{}
+	n, err := w.DrainCapture(dst)
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertString(t, testOutput, dst.String())
+	assertString(t, "", string(w.Captured()))
+	fatalOn(t, w.VerifyCapture(0))
+	assertInt64(t, testOutputLength, w.N())
+
+	stringify(w, testInput)
+	assertString(t, testOutput, string(w.Captured()))
+	assertInt64(t, 2*testOutputLength, w.N())
+}
@@ -0,0 +1,61 @@
+package demo
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrChainEnd is returned by Advance when there are no more writers in the
+// chain.
+var ErrChainEnd = errors.New("demo: no more writers in chain")
+
+// ChainAggregatedWriter is an AggregatedWriter that writes to each writer of
+// a sequence in turn. N spans the whole chain.
+type ChainAggregatedWriter struct {
+	*AggregatedWriter
+	ws []io.Writer
+	i  int
+}
+
+// NewChainAggregatedWriter returns a ChainAggregatedWriter that writes to the
+// first of ws until Advance is called.
+func NewChainAggregatedWriter(ws ...io.Writer) *ChainAggregatedWriter {
+	c := &ChainAggregatedWriter{AggregatedWriter: &AggregatedWriter{}, ws: ws}
+	if len(ws) == 0 {
+		c.err = ErrChainEnd
+		return c
+	}
+	c.w = ws[0]
+	return c
+}
+
+// Advance flushes and closes the current writer, if it supports either, and
+// directs subsequent writes to the next writer in the chain.
+func (c *ChainAggregatedWriter) Advance() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.i+1 >= len(c.ws) {
+		return ErrChainEnd
+	}
+	if err := finalize(c.w); err != nil {
+		c.err = err
+		return err
+	}
+	c.i++
+	c.w = c.ws[c.i]
+	return nil
+}
+
+// finalize flushes and then closes w, if it supports either.
+func finalize(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
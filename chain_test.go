@@ -0,0 +1,41 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestChainAggregatedWriter(t *testing.T) {
+	header, body, footer := &bytes.Buffer{}, &closeCounter{}, &bytes.Buffer{}
+	w := NewChainAggregatedWriter(header, body, footer)
+	fmt.Fprint(w, "head")
+	fatalOn(t, w.Advance())
+	fmt.Fprint(w, testOutput)
+	fatalOn(t, w.Advance())
+	fmt.Fprint(w, "foot")
+	if err := w.Advance(); err != ErrChainEnd {
+		t.Errorf("expected %v, got: %v", ErrChainEnd, err)
+	}
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, 8+testOutputLength, n)
+	assertString(t, "head", header.String())
+	assertString(t, testOutput, body.String())
+	assertString(t, "foot", footer.String())
+	assertInt64(t, 1, int64(body.closed))
+}
+
+func TestChainAggregatedWriterStickyError(t *testing.T) {
+	expect := errors.New("broken")
+	b := &bytes.Buffer{}
+	w := NewChainAggregatedWriter(&errWriter{err: expect}, b)
+	fmt.Fprint(w, "foo")
+	if err := w.Advance(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	fmt.Fprint(w, "bar")
+	assertString(t, "", b.String())
+}
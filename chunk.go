@@ -0,0 +1,30 @@
+package demo
+
+import "io"
+
+// WithMaxChunk configures w to split writes into calls of at most size bytes
+// to the underlying writer. If any chunk is not written in full, Write
+// returns the bytes written so far with the underlying error, or
+// io.ErrShortWrite.
+func WithMaxChunk(size int) Option {
+	return func(w *AggregatedWriter) { w.maxChunk = size }
+}
+
+func (w *AggregatedWriter) writeChunked(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > w.maxChunk {
+			chunk = chunk[:w.maxChunk]
+		}
+		nn, err := w.write(chunk)
+		n += nn
+		if err != nil {
+			return n, err
+		}
+		if nn < len(chunk) {
+			return n, io.ErrShortWrite
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
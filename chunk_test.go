@@ -0,0 +1,61 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// shortWriter accepts at most max bytes from the call numbered short, and
+// everything from other calls.
+type shortWriter struct {
+	bytes.Buffer
+	calls int
+	short int
+	max   int
+	err   error
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.calls == w.short && len(p) > w.max {
+		n, _ := w.Buffer.Write(p[:w.max])
+		return n, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestMaxChunk(t *testing.T) {
+	b := &shortWriter{}
+	w := NewAggregatedWriter(b, WithMaxChunk(8))
+	n, err := w.Write([]byte(testOutput))
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, int64(n))
+	assertInt64(t, 3, int64(b.calls))
+	assertString(t, testOutput, b.String())
+}
+
+func TestMaxChunkShortWrite(t *testing.T) {
+	b := &shortWriter{short: 2, max: 3}
+	w := NewAggregatedWriter(b, WithMaxChunk(8))
+	n, err := w.Write([]byte(testOutput))
+	if err != io.ErrShortWrite {
+		t.Errorf("expected %v, got: %v", io.ErrShortWrite, err)
+	}
+	assertInt64(t, 8+3, int64(n))
+	assertInt64(t, 8+3, w.N())
+	assertInt64(t, 2, int64(b.calls))
+	assertString(t, testOutput[:11], b.String())
+}
+
+func TestMaxChunkPartialError(t *testing.T) {
+	expect := errors.New("broken")
+	b := &shortWriter{short: 2, max: 3, err: expect}
+	w := NewAggregatedWriter(b, WithMaxChunk(8))
+	n, err := w.Write([]byte(testOutput))
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	assertInt64(t, 8+3, int64(n))
+}
@@ -0,0 +1,37 @@
+package demo
+
+import "crypto/cipher"
+
+// WithStreamCipher configures w to encrypt every byte with s, such as an
+// AES-CTR stream, before it reaches the underlying writer. N and any
+// observers, such as hashes and captures, see the ciphertext; Plaintext
+// counts the bytes that were encrypted and written.
+//
+// A cipher.Stream is stateful, so bytes must be encrypted in the order they
+// are written, and s must not be shared. Use WithLocking if w is written to
+// from several goroutines. If the underlying writer accepts only part of a
+// write, the keystream for the rest has been consumed and the stream cannot
+// be resumed. Heartbeats are encrypted as part of the stream. Trailers and
+// MACs written by Close follow the ciphertext unencrypted, and WriteAt fails
+// with ErrWriteAtEncrypted.
+func WithStreamCipher(s cipher.Stream) Option {
+	return func(w *AggregatedWriter) { w.cipher = s }
+}
+
+// Plaintext returns the number of bytes encrypted with the stream
+// configured by WithStreamCipher and accepted by the underlying writer.
+func (w *AggregatedWriter) Plaintext() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.plaintext
+}
+
+// encrypt returns the encryption of p, leaving p unmodified.
+func (w *AggregatedWriter) encrypt(p []byte) []byte {
+	if cap(w.cipherBuf) < len(p) {
+		w.cipherBuf = make([]byte, len(p))
+	}
+	buf := w.cipherBuf[:len(p)]
+	w.cipher.XORKeyStream(buf, p)
+	return buf
+}
@@ -0,0 +1,75 @@
+package demo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamCipher(t *testing.T) {
+	key, iv := make([]byte, 16), make([]byte, aes.BlockSize)
+	block, err := aes.NewCipher(key)
+	fatalOn(t, err)
+
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithStreamCipher(cipher.NewCTR(block, iv)))
+	stringify(w, testInput)
+	p := []byte("foo")
+	w.Write(p)
+	assertString(t, "foo", string(p))
+	fatalOn(t, w.Close())
+
+	assertInt64(t, testOutputLength+3, w.N())
+	assertInt64(t, testOutputLength+3, w.Plaintext())
+	if bytes.Contains(b.Bytes(), []byte("foo")) {
+		t.Errorf("expected ciphertext, got: %q", b.Bytes())
+	}
+
+	plain := make([]byte, b.Len())
+	cipher.NewCTR(block, iv).XORKeyStream(plain, b.Bytes())
+	assertString(t, testOutput+"foo", string(plain))
+}
+
+func TestStreamCipherHeartbeats(t *testing.T) {
+	key, iv := make([]byte, 16), make([]byte, aes.BlockSize)
+	block, err := aes.NewCipher(key)
+	fatalOn(t, err)
+
+	clock := newFakeClock()
+	b := &bytes.Buffer{}
+	w := newHeartbeatWriter(t, clock, b, WithStreamCipher(cipher.NewCTR(block, iv)))
+	w.Write([]byte("foo"))
+	tick(t, clock, testHeartbeatInterval)
+	w.Write([]byte("bar"))
+	fatalOn(t, w.Close())
+	assertInt64(t, 1, w.HeartbeatBytes())
+	assertInt64(t, 6, w.Plaintext())
+
+	plain := make([]byte, b.Len())
+	cipher.NewCTR(block, iv).XORKeyStream(plain, b.Bytes())
+	assertString(t, "foo\nbar", string(plain))
+}
+
+func TestStreamCipherWriteAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+	f, err := os.Create(filepath.Join(dir, "secret"))
+	fatalOn(t, err)
+
+	block, err := aes.NewCipher(make([]byte, 16))
+	fatalOn(t, err)
+	w := NewAggregatedWriter(f, WithStreamCipher(cipher.NewCTR(block, make([]byte, aes.BlockSize))))
+	if _, err := w.WriteAt([]byte("secret plaintext"), 0); err != ErrWriteAtEncrypted {
+		t.Errorf("expected %v, got: %v", ErrWriteAtEncrypted, err)
+	}
+	fatalOn(t, w.Close())
+	assertInt64(t, 0, w.N())
+	b, err := ioutil.ReadFile(f.Name())
+	fatalOn(t, err)
+	assertString(t, "", string(b))
+}
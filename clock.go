@@ -0,0 +1,42 @@
+package demo
+
+import "time"
+
+// Clock provides the current time and timers to an AggregatedWriter, so that
+// timing features such as throughput, latency and heartbeats can be driven
+// deterministically in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock, like *time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// WithClock configures w to use c in place of the system clock.
+func WithClock(c Clock) Option {
+	return func(w *AggregatedWriter) { w.clock = c }
+}
+
+// realClock is the system clock, used unless WithClock is given.
+type realClock struct{}
+
+func (realClock) Now() time.Time                 { return time.Now() }
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+func (w *AggregatedWriter) now() time.Time { return w.getClock().Now() }
+
+func (w *AggregatedWriter) getClock() Clock {
+	if w.clock == nil {
+		return realClock{}
+	}
+	return w.clock
+}
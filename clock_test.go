@@ -0,0 +1,128 @@
+package demo
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	armed  chan struct{} // signalled when a timer is created or reset
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), armed: make(chan struct{}, 64)}
+}
+
+// awaitTimer waits for a timer to be created or reset, such as by a
+// background goroutine that has finished handling the last one to fire.
+func (c *fakeClock) awaitTimer(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.armed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a timer")
+	}
+}
+
+// arm signals awaitTimer. c.mu must be held.
+func (c *fakeClock) arm() {
+	select {
+	case c.armed <- struct{}{}:
+	default:
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1), at: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	c.arm()
+	return t
+}
+
+// Advance moves the clock forward by d, firing any timers that fall due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.at.After(c.now) {
+			t.active = false
+			select {
+			case t.ch <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+type fakeTimer struct {
+	c      *fakeClock
+	ch     chan time.Time
+	at     time.Time
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	active := t.active
+	t.at, t.active = t.c.now.Add(d), true
+	t.c.arm()
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+func TestETA(t *testing.T) {
+	clock := newFakeClock()
+	w := NewAggregatedWriter(ioutil.Discard, WithClock(clock), WithThroughputWindow(time.Second), WithExpectedFinalN(1000))
+	if _, ok := w.ETA(); ok {
+		t.Errorf("expected no ETA before any throughput")
+	}
+	w.Write(make([]byte, 100))
+	clock.Advance(500 * time.Millisecond)
+	w.Write(make([]byte, 100))
+	if rate := w.Throughput(); rate != 200 {
+		t.Errorf("expected 200, got: %f", rate)
+	}
+	if eta, ok := w.ETA(); !ok || eta != 4*time.Second {
+		t.Errorf("expected 4s, got: %v, %v", eta, ok)
+	}
+
+	clock.Advance(2 * time.Second)
+	if _, ok := w.ETA(); ok {
+		t.Errorf("expected no ETA once throughput expires")
+	}
+	w.Write(make([]byte, 800))
+	if eta, ok := w.ETA(); !ok || eta != 0 {
+		t.Errorf("expected 0, got: %v, %v", eta, ok)
+	}
+
+	w = NewAggregatedWriter(ioutil.Discard, WithClock(clock))
+	w.Write(make([]byte, 100))
+	if _, ok := w.ETA(); ok {
+		t.Errorf("expected no ETA without an expected total")
+	}
+}
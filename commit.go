@@ -0,0 +1,31 @@
+package demo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBadCommit is returned by Commit for an offset outside [0, N].
+var ErrBadCommit = errors.New("demo: commit offset out of range")
+
+// Commit records offset as the number of bytes the destination has
+// acknowledged, for example by a server accepting a resumable upload. Unlike
+// N, which counts bytes handed to the underlying writer, the committed
+// offset is where an upload may safely resume after a reconnect. Commit
+// fails with ErrBadCommit if offset is negative or greater than N.
+func (w *AggregatedWriter) Commit(offset int64) error {
+	w.lock()
+	defer w.unlock()
+	if offset < 0 || offset > w.n {
+		return fmt.Errorf("%w: %d not in [0, %d]", ErrBadCommit, offset, w.n)
+	}
+	w.committed = offset
+	return nil
+}
+
+// Committed returns the offset last recorded by Commit.
+func (w *AggregatedWriter) Committed() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.committed
+}
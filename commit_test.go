@@ -0,0 +1,33 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCommit(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	assertInt64(t, 0, w.Committed())
+	fatalOn(t, w.Commit(0))
+
+	stringify(w, testInput)
+	fatalOn(t, w.Commit(8))
+	w.Write([]byte("foo"))
+	assertInt64(t, 8, w.Committed())
+	assertInt64(t, testOutputLength+3, w.N())
+
+	fatalOn(t, w.Commit(w.N()))
+	assertInt64(t, w.N(), w.Committed())
+
+	for _, offset := range []int64{-1, w.N() + 1} {
+		if err := w.Commit(offset); !errors.Is(err, ErrBadCommit) {
+			t.Errorf("expected %v, got: %v", ErrBadCommit, err)
+		}
+	}
+	assertInt64(t, w.N(), w.Committed())
+	fatalOn(t, w.Err())
+
+	w.Reset(&bytes.Buffer{})
+	assertInt64(t, 0, w.Committed())
+}
@@ -0,0 +1,131 @@
+package demo
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// Codec creates compressing writers for a CompressedAggregatedWriter.
+type Codec interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// CodecFunc adapts a function to the Codec interface.
+type CodecFunc func(w io.Writer) (io.WriteCloser, error)
+
+func (f CodecFunc) NewWriter(w io.Writer) (io.WriteCloser, error) { return f(w) }
+
+// GzipCodec returns a Codec that compresses with gzip at the given level.
+func GzipCodec(level int) Codec {
+	return CodecFunc(func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+// ZlibCodec returns a Codec that compresses with zlib at the given level.
+func ZlibCodec(level int) Codec {
+	return CodecFunc(func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, level)
+	})
+}
+
+// FlateCodec returns a Codec that compresses with DEFLATE at the given level.
+func FlateCodec(level int) Codec {
+	return CodecFunc(func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+}
+
+// CompressedAggregatedWriter compresses everything written to it. N reports
+// the compressed bytes written to the underlying writer and Uncompressed
+// reports the bytes written before compression.
+type CompressedAggregatedWriter struct {
+	ag     *AggregatedWriter
+	zw     io.WriteCloser
+	u      int64
+	closed bool
+}
+
+// NewCompressedAggregatedWriter returns a CompressedAggregatedWriter that
+// compresses to w using codec. The stream is only complete once Close is
+// called.
+func NewCompressedAggregatedWriter(w io.Writer, codec Codec) (*CompressedAggregatedWriter, error) {
+	ag := &AggregatedWriter{w: w}
+	zw, err := codec.NewWriter(ag)
+	if err != nil {
+		return nil, err
+	}
+	return &CompressedAggregatedWriter{ag: ag, zw: zw}, nil
+}
+
+func (w *CompressedAggregatedWriter) Write(p []byte) (n int, err error) {
+	if w.closed {
+		w.ag.latch(io.ErrClosedPipe)
+	}
+	if w.ag.err != nil {
+		return 0, w.ag.err
+	}
+	n, err = w.zw.Write(p)
+	w.u += int64(n)
+	if err != nil {
+		w.fail(err)
+	}
+	return
+}
+
+// Flush flushes any pending compressed data to the underlying writer, if the
+// codec's writer supports flushing.
+func (w *CompressedAggregatedWriter) Flush() error {
+	if f, ok := w.zw.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return w.fail(err)
+		}
+	}
+	return w.ag.Flush()
+}
+
+// Close finalizes the compressed stream and closes the underlying writer if
+// it implements io.Closer.
+func (w *CompressedAggregatedWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	// The underlying writer is closed even if the stream cannot be
+	// finalized, and the first error is returned.
+	err := w.zw.Close()
+	if err != nil {
+		w.fail(err)
+	}
+	if cerr := w.ag.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// fail stores err, unless a failed write to the underlying writer has
+// already stored an error, and returns it.
+func (w *CompressedAggregatedWriter) fail(err error) error {
+	if w.ag.err == nil {
+		w.ag.fail(err)
+	}
+	return err
+}
+
+// Uncompressed returns the number of bytes written before compression.
+func (w *CompressedAggregatedWriter) Uncompressed() int64 { return w.u }
+
+// CompressionRatio returns the ratio of uncompressed to compressed bytes, or
+// zero if nothing has been written to the underlying writer.
+func (w *CompressedAggregatedWriter) CompressionRatio() float64 {
+	if w.ag.n == 0 {
+		return 0
+	}
+	return float64(w.u) / float64(w.ag.n)
+}
+
+func (w *CompressedAggregatedWriter) N() int64                     { return w.ag.N() }
+func (w *CompressedAggregatedWriter) Err() error                   { return w.ag.Err() }
+func (w *CompressedAggregatedWriter) Result() (n int64, err error) { return w.ag.Result() }
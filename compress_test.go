@@ -0,0 +1,79 @@
+package demo
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func testCodec(t *testing.T, codec Codec, newReader func(io.Reader) (io.Reader, error)) {
+	input := strings.Repeat(testOutput, 64)
+	b := &bytes.Buffer{}
+	w, err := NewCompressedAggregatedWriter(b, codec)
+	fatalOn(t, err)
+	fmt.Fprint(w, input)
+	fatalOn(t, w.Close())
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, int64(b.Len()), n)
+	assertInt64(t, int64(len(input)), w.Uncompressed())
+	if w.CompressionRatio() <= 1 {
+		t.Errorf("expected compression ratio above 1, got: %f", w.CompressionRatio())
+	}
+
+	r, err := newReader(b)
+	fatalOn(t, err)
+	output, err := ioutil.ReadAll(r)
+	fatalOn(t, err)
+	assertString(t, input, string(output))
+}
+
+func TestGzipCodec(t *testing.T) {
+	testCodec(t, GzipCodec(gzip.BestCompression), func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+func TestZlibCodec(t *testing.T) {
+	testCodec(t, ZlibCodec(zlib.DefaultCompression), func(r io.Reader) (io.Reader, error) {
+		return zlib.NewReader(r)
+	})
+}
+
+func TestFlateCodec(t *testing.T) {
+	testCodec(t, FlateCodec(flate.BestSpeed), func(r io.Reader) (io.Reader, error) {
+		return flate.NewReader(r), nil
+	})
+}
+
+func TestCompressedWriteAfterClose(t *testing.T) {
+	w, err := NewCompressedAggregatedWriter(ioutil.Discard, GzipCodec(gzip.DefaultCompression))
+	fatalOn(t, err)
+	fatalOn(t, w.Close())
+	if _, err := w.Write([]byte("foo")); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+}
+
+func TestCompressedCloseError(t *testing.T) {
+	expect := errors.New("disk full")
+	b := &scriptedCloser{scriptedWriter: scriptedWriter{errs: []error{expect}}}
+	w, err := NewCompressedAggregatedWriter(b, GzipCodec(gzip.DefaultCompression))
+	fatalOn(t, err)
+	fmt.Fprint(w, testOutput)
+	if err := w.Close(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	assertInt64(t, 1, int64(b.closed))
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+}
@@ -0,0 +1,62 @@
+package demo
+
+import (
+	"io"
+	"net"
+)
+
+// AggregatedReadWriter counts the bytes read from and written to an
+// io.ReadWriter, storing the first error in each direction independently.
+type AggregatedReadWriter struct {
+	r *AggregatedReader
+	w *AggregatedWriter
+}
+
+// NewAggregatedReadWriter returns an AggregatedReadWriter that reads from
+// and writes to rw. Options configure the writing side.
+func NewAggregatedReadWriter(rw io.ReadWriter, opts ...Option) *AggregatedReadWriter {
+	return &AggregatedReadWriter{
+		r: NewAggregatedReader(rw),
+		w: NewAggregatedWriter(rw, opts...),
+	}
+}
+
+func (rw *AggregatedReadWriter) Read(p []byte) (int, error)  { return rw.r.Read(p) }
+func (rw *AggregatedReadWriter) Write(p []byte) (int, error) { return rw.w.Write(p) }
+
+// Close closes the writing side, which closes the underlying io.ReadWriter
+// if it implements io.Closer.
+func (rw *AggregatedReadWriter) Close() error { return rw.w.Close() }
+
+// Reader returns the reading side of rw.
+func (rw *AggregatedReadWriter) Reader() *AggregatedReader { return rw.r }
+
+// Writer returns the writing side of rw.
+func (rw *AggregatedReadWriter) Writer() *AggregatedWriter { return rw.w }
+
+// Result returns the bytes read and written, and the errors from both
+// directions joined, for example to log a summary of a connection.
+func (rw *AggregatedReadWriter) Result() (read, written int64, err error) {
+	return rw.r.N(), rw.w.N(), joinErrors(rw.r.Err(), rw.w.Err())
+}
+
+// AggregatedConn is a net.Conn that counts the bytes read and written, as
+// returned by WrapConn.
+type AggregatedConn struct {
+	net.Conn
+	*AggregatedReadWriter
+}
+
+// WrapConn returns c wrapped to count the bytes read from and written to
+// it. Deadlines and addresses are those of c. A read that times out is not
+// stored as the read error, so reading may resume once the deadline is
+// extended.
+func WrapConn(c net.Conn, opts ...Option) *AggregatedConn {
+	return &AggregatedConn{Conn: c, AggregatedReadWriter: NewAggregatedReadWriter(c, opts...)}
+}
+
+func (c *AggregatedConn) Read(p []byte) (int, error)  { return c.AggregatedReadWriter.Read(p) }
+func (c *AggregatedConn) Write(p []byte) (int, error) { return c.AggregatedReadWriter.Write(p) }
+
+// Close flushes and closes the connection.
+func (c *AggregatedConn) Close() error { return c.AggregatedReadWriter.Close() }
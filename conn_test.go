@@ -0,0 +1,93 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+var _ net.Conn = (*AggregatedConn)(nil)
+
+func TestAggregatedReadWriter(t *testing.T) {
+	expect := errors.New("broken")
+	rw := NewAggregatedReadWriter(struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewBufferString(testOutput), &errWriter{n: 3, err: expect}})
+	b, err := ioutil.ReadAll(rw)
+	fatalOn(t, err)
+	assertString(t, testOutput, string(b))
+	rw.Write([]byte("foobar"))
+
+	read, written, err := rw.Result()
+	assertInt64(t, testOutputLength, read)
+	assertInt64(t, 3, written)
+	if !errors.Is(err, expect) {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	fatalOn(t, rw.Reader().Err())
+}
+
+func TestWrapConn(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		b := make([]byte, testOutputLength)
+		io.ReadFull(server, b)
+		server.Write(b)
+		server.Close()
+	}()
+
+	c := WrapConn(client)
+	stringify(c, testInput)
+	b := make([]byte, testOutputLength)
+	_, err := io.ReadFull(c, b)
+	fatalOn(t, err)
+	assertString(t, testOutput, string(b))
+
+	fatalOn(t, c.SetReadDeadline(time.Now().Add(-time.Second)))
+	_, err = c.Read(b)
+	if err, ok := err.(net.Error); !ok || !err.Timeout() {
+		t.Errorf("expected a timeout, got: %v", err)
+	}
+	fatalOn(t, c.Close())
+	if _, err := c.Write([]byte("foo")); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+
+	read, written, err := c.Result()
+	assertInt64(t, testOutputLength, read)
+	assertInt64(t, testOutputLength, written)
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	fatalOn(t, c.Reader().Err())
+}
+
+func TestWrapConnReadDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := WrapConn(client)
+	defer c.Close()
+
+	b := make([]byte, 3)
+	fatalOn(t, c.SetReadDeadline(time.Now().Add(-time.Second)))
+	if _, err := c.Read(b); !isTimeout(err) {
+		t.Fatalf("expected a timeout, got: %v", err)
+	}
+	fatalOn(t, c.SetReadDeadline(time.Time{}))
+	go server.Write([]byte("foo"))
+	_, err := io.ReadFull(c, b)
+	fatalOn(t, err)
+	assertString(t, "foo", string(b))
+	fatalOn(t, c.Reader().Err())
+	assertInt64(t, 3, c.Reader().N())
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
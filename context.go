@@ -0,0 +1,30 @@
+package demo
+
+import "context"
+
+// FlushContext is like Flush but returns ctx.Err() if ctx is done before the
+// flush completes. The flush continues in the background once started, so w
+// must not be used again after FlushContext returns a context error.
+func (w *AggregatedWriter) FlushContext(ctx context.Context) error {
+	return runContext(ctx, w.Flush)
+}
+
+// CloseContext is like Close but returns ctx.Err() if ctx is done before the
+// close completes. The close continues in the background once started.
+func (w *AggregatedWriter) CloseContext(ctx context.Context) error {
+	return runContext(ctx, w.Close)
+}
+
+func runContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
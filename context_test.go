@@ -0,0 +1,63 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingFlusher blocks in Flush and Close until release is closed.
+type blockingFlusher struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (b *blockingFlusher) Flush() error {
+	<-b.release
+	return nil
+}
+
+func (b *blockingFlusher) Close() error {
+	<-b.release
+	return nil
+}
+
+func TestFlushContext(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	stringify(w, testInput)
+	fatalOn(t, w.FlushContext(context.Background()))
+	fatalOn(t, w.CloseContext(context.Background()))
+}
+
+func TestFlushContextTimeout(t *testing.T) {
+	for _, fn := range []func(*AggregatedWriter, context.Context) error{
+		(*AggregatedWriter).FlushContext,
+		(*AggregatedWriter).CloseContext,
+	} {
+		b := &blockingFlusher{release: make(chan struct{})}
+		w := NewAggregatedWriter(b)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		start := time.Now()
+		err := fn(w, ctx)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected %v, got: %v", context.DeadlineExceeded, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected prompt return, took: %v", elapsed)
+		}
+		close(b.release)
+	}
+}
+
+func TestFlushContextCanceled(t *testing.T) {
+	b := &blockingFlusher{release: make(chan struct{})}
+	defer close(b.release)
+	w := NewAggregatedWriter(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.FlushContext(ctx); err != context.Canceled {
+		t.Errorf("expected %v, got: %v", context.Canceled, err)
+	}
+}
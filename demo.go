@@ -1,30 +1,554 @@
 package demo
 
-import "io"
+import (
+	"crypto/cipher"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
 
 type AggregatedWriter struct {
-	w   io.Writer
-	n   int64
-	err error
+	view StatsView // first, so its counters are 64-bit aligned for atomics
+
+	w         io.Writer
+	n         int64
+	err       error
+	closed    bool
+	writes    int64 // successful calls to Write
+	errors    int64 // failed calls to Write
+	unflushed int64 // bytes written since the last flush
+	touched   bool  // whether the underlying writer has been written to
+	plain     bool  // no options, so fast paths may bypass write
+
+	factory func() (io.Writer, error)
+
+	// error handling
+	mapErr          func(error) error
+	continueOnError bool
+	errs            *errorSet
+	recoverPanics   bool
+	shortWrites     ShortWritePolicy
+	maxErrLen       int
+
+	// guards checked before writing
+	token          []byte
+	maxSingleWrite int
+	limit          int64
+	truncate       bool // write up to limit before failing
+	maxWrites      int64
+	validateSize   func(size int) error
+
+	// transforms applied before writing
+	interceptors   []Interceptor
+	chain          WriteFunc
+	rejected       error // limit error from transform, if the chain was rejected
+	prefix, suffix []byte
+	wrapBuf        []byte
+	maxChunk       int
+	copyBuf        []byte
+	numBuf         [64]byte // scratch space for WriteInt and WriteFloat
+	cipher         cipher.Stream
+	cipherBuf      []byte
+	plaintext      int64
+
+	shouldFlush func(lastWrite []byte, total int64) bool
+
+	// observers of bytes accepted by the underlying writer
+	lines          *lineSplitter
+	capture        *capture
+	hashes         []hash.Hash
+	tees           []io.Writer
+	teeErrs        []error
+	firstByte      func(at time.Time)
+	firstByteFired bool
+	onWrite        func(n, total int64)
+	onError        func(err error)
+	metrics        Metrics
+	rate           *throughput
+	latency        *latencies
+
+	// reporting
+	blockSize  int64
+	recordSize int64
+	minWrite   int64 // smallest successful write
+	maxWrite   int64 // largest successful write
+	firstWrite time.Time
+	lastWrite  time.Time
+	hwm        int64 // highest offset written by WriteAt
+	committed  int64 // offset recorded by Commit
+
+	// checked by Close
+	expectN int64
+	checkN  bool
+	alignTo int64
+
+	// written by Close
+	trailer  func(Stats) []byte
+	trailerN int64
+	mac      hash.Hash
+	macN     int64
+
+	// guards all of the above when locking is set, by WithLocking or
+	// features with background goroutines
+	mu        sync.Mutex
+	locking   bool
+	heartbeat *heartbeat
+
+	clock Clock
 }
 
-func NewAggregatedWriter(w io.Writer) *AggregatedWriter {
-	if ag, ok := w.(*AggregatedWriter); ok {
+// Option configures an AggregatedWriter.
+type Option func(*AggregatedWriter)
+
+// NewAggregatedWriter returns an AggregatedWriter that writes to w.
+//
+// If w is already an *AggregatedWriter and no options are given, w itself is
+// returned, so that nested calls share a single count, error and any
+// features configured on w. If options are given, a new AggregatedWriter is
+// returned that writes through w; the two are never merged, so each keeps
+// its own count and features, and w still sees every byte written.
+func NewAggregatedWriter(w io.Writer, opts ...Option) *AggregatedWriter {
+	if ag, ok := w.(*AggregatedWriter); ok && len(opts) == 0 {
 		return ag
 	}
-	return &AggregatedWriter{w: w}
+	ag := &AggregatedWriter{w: w, plain: len(opts) == 0}
+	for _, opt := range opts {
+		opt(ag)
+	}
+	if ag.heartbeat != nil {
+		ag.heartbeat.start(ag)
+	}
+	return ag
 }
 
+// Write implements io.Writer. If the underlying writer fails, Write returns
+// the number of bytes it accepted along with the error; every later call
+// returns the same error without writing anything.
 func (w *AggregatedWriter) Write(p []byte) (n int, err error) {
-	if w.err != nil {
-		return 0, w.err
+	w.lock()
+	defer w.unlock()
+	return w.writeLocked(p)
+}
+
+// writeLocked implements Write once w is locked.
+func (w *AggregatedWriter) writeLocked(p []byte) (n int, err error) {
+	if err = w.check(); err != nil {
+		return 0, err
 	}
-	n, err = w.w.Write(p)
-	w.n += int64(n)
+	if w.token != nil {
+		return 0, w.fail(ErrUnauthorized)
+	}
+	return w.writeChecked(p)
+}
+
+// check returns the error that should prevent the next write, if any.
+func (w *AggregatedWriter) check() error {
+	if w.closed {
+		w.latch(io.ErrClosedPipe)
+	}
+	if w.err != nil && (w.closed || !w.continueOnError) {
+		return w.err
+	}
+	return nil
+}
+
+// fail stores err as the result of a failed write and returns it.
+func (w *AggregatedWriter) fail(err error) error {
+	first := w.err == nil
 	w.err = err
+	if w.errs != nil {
+		w.errs.add(err, w.n)
+	}
+	if first && w.onError != nil {
+		w.onError(err)
+	}
+	return err
+}
+
+// latch stores err unless an error is already stored.
+func (w *AggregatedWriter) latch(err error) {
+	if w.err == nil {
+		w.err = err
+		if w.onError != nil {
+			w.onError(err)
+		}
+	}
+}
+
+// writeChecked writes p, applying any configured transforms, once check has
+// passed.
+func (w *AggregatedWriter) writeChecked(p []byte) (n int, err error) {
+	p, lerr := w.truncateToLimit(p)
+	if lerr != nil && len(p) == 0 {
+		return 0, w.fail(lerr)
+	}
+	if err = w.guard(p); err != nil {
+		return 0, w.fail(err)
+	}
+	if w.chain != nil {
+		// Interceptors may change the size of p, so transform checks the
+		// limit once it is known. A write it rejects never reached the
+		// underlying writer and is not counted, as for any other limit.
+		w.rejected = nil
+		n, err = w.chain(p)
+		n = clampN(n, 0, len(p))
+		if w.rejected != nil {
+			err, w.rejected = w.rejected, nil
+			return n, w.fail(err)
+		}
+	} else if err = w.checkLimit(len(p) + w.overhead()); err != nil {
+		return 0, w.fail(err)
+	} else {
+		n, err = w.transform(p)
+	}
+	if err == nil {
+		// A truncated write reaches the underlying writer but still fails.
+		err = lerr
+	}
+	if err == nil {
+		w.writes++
+		w.view.addWrite()
+		w.observeWrite(int64(n))
+		if w.onWrite != nil {
+			w.onWrite(int64(n), w.n)
+		}
+	} else {
+		w.errors++
+		w.view.addError()
+	}
+	if w.metrics != nil {
+		w.metrics.ObserveWrite(n, err)
+	}
+	if err != nil {
+		w.fail(err)
+	} else if w.shouldFlush != nil && w.shouldFlush(p, w.n) {
+		w.flush()
+	}
+	return
+}
+
+// transform writes p with any configured prefix and suffix, checking the
+// limit first if p has passed through interceptors.
+func (w *AggregatedWriter) transform(p []byte) (n int, err error) {
+	if w.chain != nil {
+		if err = w.checkLimit(len(p) + w.overhead()); err != nil {
+			w.rejected = err
+			return 0, err
+		}
+	}
+	if len(w.prefix) > 0 || len(w.suffix) > 0 {
+		return w.writeWrapped(p)
+	}
+	return w.writeOut(p)
+}
+
+// writeOut writes p to the underlying writer, in chunks if configured.
+func (w *AggregatedWriter) writeOut(p []byte) (n int, err error) {
+	if w.maxChunk > 0 {
+		return w.writeChunked(p)
+	}
+	return w.write(p)
+}
+
+// write writes p to the underlying writer and accounts for the bytes it
+// accepted.
+func (w *AggregatedWriter) write(p []byte) (n int, err error) {
+	if err = w.ensureWriter(); err != nil {
+		return 0, err
+	}
+	if w.cipher != nil {
+		p = w.encrypt(p)
+	}
+	w.touched = true
+	if w.heartbeat != nil {
+		w.heartbeat.last = w.now()
+	}
+	var start time.Time
+	if w.latency != nil {
+		start = w.now()
+	}
+	n, err = w.sinkWrite(p)
+	if w.latency != nil {
+		w.latency.add(w.now().Sub(start))
+	}
+	if n < 0 || n > len(p) {
+		n = clampN(n, 0, len(p))
+		if err == nil {
+			err = ErrBadWriteCount
+		}
+	}
+	w.n += int64(n)
+	w.view.addBytes(int64(n))
+	w.unflushed += int64(n)
+	if w.cipher != nil {
+		w.plaintext += int64(n)
+	}
+	w.observe(p[:n])
+	return
+}
+
+// sinkWrite writes p, which has already been encrypted if configured, to
+// the underlying writer with panic recovery, the short write policy and
+// error mapping applied, but without accounting for it.
+func (w *AggregatedWriter) sinkWrite(p []byte) (n int, err error) {
+	n, err = w.writeUnderlying(p)
+	if err != nil && w.mapErr != nil {
+		err = w.mapErr(err)
+	}
 	return
 }
 
-func (w *AggregatedWriter) N() int64                     { return w.n }
-func (w *AggregatedWriter) Err() error                   { return w.err }
-func (w *AggregatedWriter) Result() (n int64, err error) { return w.n, w.err }
+// observe passes bytes accepted by the underlying writer to any configured
+// observers.
+func (w *AggregatedWriter) observe(p []byte) {
+	if w.lines != nil {
+		w.lines.write(p)
+	}
+	if w.capture != nil {
+		w.capture.write(p)
+	}
+	for _, h := range w.hashes {
+		h.Write(p)
+	}
+	if w.mac != nil {
+		w.mac.Write(p)
+	}
+	if w.tees != nil {
+		w.tee(p)
+	}
+	if w.rate != nil {
+		w.rate.add(w.now(), len(p))
+	}
+	if w.firstByte != nil && !w.firstByteFired && len(p) > 0 {
+		w.firstByteFired = true
+		w.firstByte(w.now())
+	}
+}
+
+// lock acquires w.mu if w is shared with a background goroutine.
+func (w *AggregatedWriter) lock() {
+	if w.locking {
+		w.mu.Lock()
+	}
+}
+
+func (w *AggregatedWriter) unlock() {
+	if w.locking {
+		w.mu.Unlock()
+	}
+}
+
+// WriteString implements io.StringWriter, delegating to the underlying
+// writer's WriteString if it has one and w has no options configured.
+func (w *AggregatedWriter) WriteString(s string) (n int, err error) {
+	if sw, ok := w.passthrough().(io.StringWriter); ok {
+		w.lock()
+		defer w.unlock()
+		if err = w.check(); err != nil {
+			return 0, err
+		}
+		n, err = sw.WriteString(s)
+		if n < 0 || n > len(s) {
+			n = clampN(n, 0, len(s))
+			if err == nil {
+				err = ErrBadWriteCount
+			}
+		}
+		w.record(int64(n), err)
+		return n, err
+	}
+	return w.Write([]byte(s))
+}
+
+// WriteByte implements io.ByteWriter.
+func (w *AggregatedWriter) WriteByte(c byte) error {
+	_, err := w.Write([]byte{c})
+	return err
+}
+
+// Flush delivers any partial line buffered for a line callback and flushes
+// the underlying writer if it implements Flush() error or http.Flusher.
+func (w *AggregatedWriter) Flush() error {
+	w.lock()
+	defer w.unlock()
+	return w.flushAll()
+}
+
+// flushAll implements Flush.
+func (w *AggregatedWriter) flushAll() error {
+	if w.lines != nil {
+		w.lines.flush()
+	}
+	return w.flush()
+}
+
+// flush flushes the underlying writer, if it supports flushing.
+func (w *AggregatedWriter) flush() error {
+	var err error
+	switch f := w.w.(type) {
+	case interface{ Flush() error }:
+		err = f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	if err != nil {
+		w.latch(err)
+		return err
+	}
+	w.unflushed = 0
+	return nil
+}
+
+// Sync flushes w and then commits the underlying writer to stable storage if
+// it implements Sync() error, as *os.File does.
+func (w *AggregatedWriter) Sync() error {
+	w.lock()
+	defer w.unlock()
+	if err := w.flushAll(); err != nil {
+		return err
+	}
+	s, ok := w.w.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+	err := s.Sync()
+	if err != nil {
+		w.latch(err)
+	}
+	return err
+}
+
+// Close flushes w and closes the underlying writer if it implements
+// io.Closer. Any write after Close fails with io.ErrClosedPipe without
+// reaching the underlying writer. Only the first call to Close has any
+// effect.
+func (w *AggregatedWriter) Close() error {
+	if w.heartbeat != nil {
+		w.heartbeat.stop()
+	}
+	w.lock()
+	defer w.unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.metrics != nil {
+		defer func() { w.metrics.ObserveClose(w.stats()) }()
+	}
+	// The underlying writer is closed even if writing the trailer or MAC, or
+	// flushing, fails, and the first error is returned.
+	err := w.writeTrailer()
+	if err == nil {
+		err = w.writeMAC()
+	}
+	if ferr := w.flushAll(); err == nil {
+		err = ferr
+	}
+	if ferr := w.checkFinal(); ferr != nil {
+		w.latch(ferr)
+		if err == nil {
+			err = ferr
+		}
+	}
+	if c, ok := w.w.(io.Closer); ok {
+		if cerr := c.Close(); cerr != nil {
+			w.latch(cerr)
+			if err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// Reset discards all state, including any stored error, and directs
+// subsequent writes to uw. Options given at construction are retained.
+func (w *AggregatedWriter) Reset(uw io.Writer) {
+	if w.heartbeat != nil {
+		w.heartbeat.stop()
+		defer w.heartbeat.start(w)
+	}
+	w.lock()
+	defer w.unlock()
+	w.w, w.factory = uw, nil
+	w.n, w.err, w.closed = 0, nil, false
+	w.writes, w.errors, w.unflushed, w.touched = 0, 0, 0, false
+	w.hwm, w.committed, w.plaintext = 0, 0, 0
+	w.minWrite, w.maxWrite = 0, 0
+	w.firstWrite, w.lastWrite = time.Time{}, time.Time{}
+	w.view.reset()
+	if w.errs != nil {
+		*w.errs = errorSet{}
+	}
+	if w.lines != nil {
+		w.lines.buf = w.lines.buf[:0]
+	}
+	if w.capture != nil {
+		*w.capture = capture{limit: w.capture.limit}
+	}
+	for _, h := range w.hashes {
+		h.Reset()
+	}
+	for i := range w.teeErrs {
+		w.teeErrs[i] = nil
+	}
+	w.firstByteFired = false
+	if w.rate != nil {
+		w.rate.samples, w.rate.total, w.rate.event = nil, 0, ""
+	}
+	if w.latency != nil {
+		*w.latency = latencies{rand: w.latency.rand}
+	}
+	if w.heartbeat != nil {
+		w.heartbeat.n, w.heartbeat.last = 0, w.now()
+	}
+	w.trailerN, w.macN = 0, 0
+	if w.mac != nil {
+		w.mac.Reset()
+	}
+}
+
+// Touched reports whether anything has been written to the underlying
+// writer, successfully or not. Writes rejected before reaching the
+// underlying writer, such as after an earlier error, do not count.
+func (w *AggregatedWriter) Touched() bool {
+	w.lock()
+	defer w.unlock()
+	return w.touched
+}
+
+// UnderlyingType returns the type of the underlying writer, such as
+// "*os.File", for diagnostics.
+func (w *AggregatedWriter) UnderlyingType() string {
+	w.lock()
+	defer w.unlock()
+	return fmt.Sprintf("%T", w.w)
+}
+
+func (w *AggregatedWriter) N() int64 { return w.view.N() }
+
+func (w *AggregatedWriter) Result() (n int64, err error) {
+	w.lock()
+	defer w.unlock()
+	return w.n, w.loadErr()
+}
+
+func (w *AggregatedWriter) Err() error {
+	w.lock()
+	defer w.unlock()
+	return w.loadErr()
+}
+
+// loadErr implements Err once w is locked.
+func (w *AggregatedWriter) loadErr() error {
+	err := w.err
+	if w.errs != nil && len(w.errs.errs) > 0 {
+		err = w.errs.err()
+	}
+	if w.maxErrLen > 0 && err != nil {
+		err = truncateError(err, w.maxErrLen)
+	}
+	return err
+}
@@ -2,6 +2,8 @@ package demo
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"testing"
@@ -117,3 +119,197 @@ func TestAggregatorCase(t *testing.T) {
 	assertInt64(t, testOutputLength, n)
 	assertString(t, testOutput, b.String())
 }
+
+type closeCounter struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	b := &closeCounter{}
+	w := NewAggregatedWriter(b)
+	fmt.Fprint(w, "foo")
+	fatalOn(t, w.Close())
+
+	if _, err := w.Write([]byte("bar")); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	if _, err := w.WriteString("bar"); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	if err := w.WriteByte('!'); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	if err := w.Err(); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	assertInt64(t, 3, w.N())
+	assertString(t, "foo", b.String())
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	b := &closeCounter{}
+	w := NewAggregatedWriter(b)
+	fatalOn(t, w.Close())
+	fatalOn(t, w.Close())
+	assertInt64(t, 1, int64(b.closed))
+}
+
+// errWriter writes up to n bytes before failing with err.
+type errWriter struct {
+	n   int
+	err error
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.n {
+		w.n -= len(p)
+		return len(p), nil
+	}
+	n := w.n
+	w.n = 0
+	return n, w.err
+}
+
+type syncSpy struct {
+	bytes.Buffer
+	synced int
+	err    error
+}
+
+func (s *syncSpy) Sync() error {
+	s.synced++
+	return s.err
+}
+
+func TestSync(t *testing.T) {
+	b := &syncSpy{}
+	w := NewAggregatedWriter(b)
+	fmt.Fprint(w, testOutput)
+	fatalOn(t, w.Sync())
+	assertInt64(t, 1, int64(b.synced))
+
+	expect := errors.New("sync failed")
+	b.err = expect
+	if err := w.Sync(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	if err := w.Err(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+}
+
+func TestSyncUnsupported(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	fatalOn(t, w.Sync())
+}
+
+func TestPartialWrite(t *testing.T) {
+	expect := errors.New("disk full")
+	w := NewAggregatedWriter(&errWriter{n: 3, err: expect})
+	n, err := w.Write([]byte("foobar"))
+	assertInt64(t, 3, int64(n))
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	assertInt64(t, 3, w.N())
+
+	n, err = w.Write([]byte("qux"))
+	assertInt64(t, 0, int64(n))
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	assertInt64(t, 3, w.N())
+}
+
+func TestTouched(t *testing.T) {
+	b := &toggleWriter{}
+	w := NewAggregatedWriter(b)
+	if w.Touched() {
+		t.Errorf("expected fresh writer to be untouched")
+	}
+	fmt.Fprint(w, "foo")
+	if !w.Touched() {
+		t.Errorf("expected writer to be touched")
+	}
+
+	w.Reset(b)
+	if w.Touched() {
+		t.Errorf("expected reset writer to be untouched")
+	}
+	w.ResetError(errors.New("broken"))
+	fmt.Fprint(w, "foo")
+	if w.Touched() {
+		t.Errorf("expected rejected write not to touch writer")
+	}
+}
+
+func TestReset(t *testing.T) {
+	a, b := &bytes.Buffer{}, &bytes.Buffer{}
+	w := NewAggregatedWriter(&errWriter{n: 3, err: errors.New("broken")}, WithInMemoryCapture(0))
+	stringify(w, testInput)
+	fatalOn(t, w.Close())
+
+	w.Reset(a)
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, 0, n)
+	assertString(t, "", string(w.Captured()))
+
+	stringify(w, testInput)
+	w.Reset(b)
+	stringify(w, testInput)
+	n, err = w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertString(t, testOutput, a.String())
+	assertString(t, testOutput, b.String())
+	assertString(t, testOutput, string(w.Captured()))
+}
+
+// overcountWriter reports writing more bytes than it was given.
+type overcountWriter struct{}
+
+func (overcountWriter) Write(p []byte) (int, error) { return len(p) + 5, nil }
+
+func TestBadWriteCount(t *testing.T) {
+	w := NewAggregatedWriter(overcountWriter{})
+	n, err := fmt.Fprint(w, "foo")
+	assertInt64(t, 3, int64(n))
+	assertInt64(t, 3, w.N())
+	if err != ErrBadWriteCount {
+		t.Errorf("expected %v, got: %v", ErrBadWriteCount, err)
+	}
+	if w.Err() != ErrBadWriteCount {
+		t.Errorf("expected %v, got: %v", ErrBadWriteCount, w.Err())
+	}
+}
+
+func TestUnderlyingType(t *testing.T) {
+	assertString(t, "*bytes.Buffer", NewAggregatedWriter(&bytes.Buffer{}).UnderlyingType())
+}
+
+func TestNestedAggregatedWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	inner := NewAggregatedWriter(b, WithHashes(sha256.New()))
+	if NewAggregatedWriter(inner) != inner {
+		t.Fatal("expected nested writer without options to be unwrapped")
+	}
+	stringify(NewAggregatedWriter(inner), testInput)
+	assertInt64(t, testOutputLength, inner.N())
+
+	outer := NewAggregatedWriter(inner, WithWrap(nil, []byte("\n")))
+	stringify(outer, testInput)
+	assertInt64(t, testOutputLength+7, outer.N())
+	assertInt64(t, 2*testOutputLength+7, inner.N())
+
+	sum := sha256.Sum256(b.Bytes())
+	if !bytes.Equal(sum[:], inner.Sums()[0]) {
+		t.Errorf("expected %x, got: %x", sum, inner.Sums()[0])
+	}
+}
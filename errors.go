@@ -0,0 +1,236 @@
+package demo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBadWriteCount is returned when the underlying writer reports writing
+// more bytes than it was given, or a negative number of bytes.
+var ErrBadWriteCount = errors.New("demo: invalid count from underlying writer")
+
+// ErrorPolicy determines how an AggregatedWriter handles write errors.
+type ErrorPolicy int
+
+const (
+	// FirstError stops writing at the first error and reports it. This is
+	// the default.
+	FirstError ErrorPolicy = iota
+
+	// CollectAll keeps writing after errors and reports every distinct
+	// error, with the number of times it occurred. Each is a *WriteError
+	// recording the offset at which it first occurred, and errors.Is and
+	// errors.As match against each of them.
+	CollectAll
+)
+
+// maxDistinctErrors bounds the number of distinct errors kept by CollectAll.
+const maxDistinctErrors = 64
+
+// WithErrorPolicy configures how w handles write errors.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(w *AggregatedWriter) {
+		switch policy {
+		case FirstError:
+			w.continueOnError, w.errs = false, nil
+		case CollectAll:
+			w.continueOnError, w.errs = true, &errorSet{}
+		}
+	}
+}
+
+// WithErrorMapper configures fn to translate any error returned by the
+// underlying writer before it is returned and stored. If fn returns nil, the
+// write is treated as successful. Mappers that wrap rather than replace the
+// error should use fmt.Errorf with %w so that errors.Is and errors.As still
+// match the original cause.
+func WithErrorMapper(fn func(error) error) Option {
+	return func(w *AggregatedWriter) { w.mapErr = fn }
+}
+
+// WithContinueOnError configures w to keep writing to the underlying writer
+// after a write fails, rather than failing fast. Err reports the most recent
+// error.
+func WithContinueOnError() Option {
+	return func(w *AggregatedWriter) { w.continueOnError = true }
+}
+
+// joinErrors returns an error that wraps each of the non-nil errs, or nil if
+// there are none.
+func joinErrors(errs ...error) error {
+	var joined multiError
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	}
+	return joined
+}
+
+// multiError is a list of errors that errors.Is and errors.As match against
+// individually.
+type multiError []error
+
+func (e multiError) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+func (e multiError) Unwrap() []error { return e }
+
+func (e multiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e multiError) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearErr clears the stored error, including any errors collected by
+// CollectAll, so that subsequent writes are attempted again. Byte and write
+// counts, and any timing state, are left untouched.
+func (w *AggregatedWriter) ClearErr() { w.ResetError(nil) }
+
+// ResetError replaces the stored error with err, which may be nil, without
+// touching any counters. It can be used to mark a writer as degraded.
+func (w *AggregatedWriter) ResetError(err error) {
+	w.lock()
+	defer w.unlock()
+	w.err = err
+	if w.errs != nil {
+		*w.errs = errorSet{}
+	}
+}
+
+// WriteError is an error collected under the CollectAll policy.
+type WriteError struct {
+	Offset int64 // N when the error first occurred
+	Err    error
+}
+
+func (e *WriteError) Error() string { return e.Err.Error() }
+func (e *WriteError) Unwrap() error { return e.Err }
+
+// Errors returns each distinct error collected under the CollectAll policy,
+// in the order they first occurred.
+func (w *AggregatedWriter) Errors() []*WriteError {
+	w.lock()
+	defer w.unlock()
+	if w.errs == nil {
+		return nil
+	}
+	errs := make([]*WriteError, len(w.errs.errs))
+	copy(errs, w.errs.errs)
+	return errs
+}
+
+// ErrorCounts returns the number of times each distinct error message was
+// seen under the CollectAll policy.
+func (w *AggregatedWriter) ErrorCounts() map[string]int {
+	w.lock()
+	defer w.unlock()
+	if w.errs == nil {
+		return nil
+	}
+	counts := make(map[string]int, len(w.errs.counts))
+	for msg, n := range w.errs.counts {
+		counts[msg] = n
+	}
+	return counts
+}
+
+// errorSet collects distinct errors by message, counting repeats.
+type errorSet struct {
+	errs    []*WriteError // first occurrence of each message
+	counts  map[string]int
+	dropped int // errors not kept because the set was full
+}
+
+func (s *errorSet) add(err error, offset int64) {
+	msg := err.Error()
+	if _, ok := s.counts[msg]; !ok {
+		if len(s.errs) >= maxDistinctErrors {
+			s.dropped++
+			return
+		}
+		if s.counts == nil {
+			s.counts = make(map[string]int)
+		}
+		s.errs = append(s.errs, &WriteError{Offset: offset, Err: err})
+	}
+	s.counts[msg]++
+}
+
+func (s *errorSet) err() error {
+	errs := make([]error, 0, len(s.errs)+1)
+	for _, err := range s.errs {
+		errs = append(errs, &countedError{err, s.counts[err.Error()]})
+	}
+	if s.dropped > 0 {
+		errs = append(errs, fmt.Errorf("%d more errors", s.dropped))
+	}
+	return multiError(errs)
+}
+
+// countedError is an error that occurred n times.
+type countedError struct {
+	err error
+	n   int
+}
+
+func (e *countedError) Error() string {
+	if e.n == 1 {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%v (%d times)", e.err, e.n)
+}
+
+func (e *countedError) Unwrap() error { return e.err }
+
+// WithMaxErrorLength configures Err to truncate error messages longer than n
+// runes. The truncated error wraps the original, so errors.Is and errors.As
+// still match it.
+func WithMaxErrorLength(n int) Option {
+	return func(w *AggregatedWriter) { w.maxErrLen = n }
+}
+
+// truncateError returns err, or a wrapper of err with a message of at most
+// max runes.
+func truncateError(err error, max int) error {
+	msg := []rune(err.Error())
+	if len(msg) <= max {
+		return err
+	}
+	return &truncatedError{
+		msg: fmt.Sprintf("%s... (%d characters)", string(msg[:max]), len(msg)),
+		err: err,
+	}
+}
+
+type truncatedError struct {
+	msg string
+	err error
+}
+
+func (e *truncatedError) Error() string { return e.msg }
+func (e *truncatedError) Unwrap() error { return e.err }
@@ -0,0 +1,195 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorMapper(t *testing.T) {
+	errPipe := errors.New("broken pipe")
+	errDisconnected := errors.New("client disconnected")
+	w := NewAggregatedWriter(&errWriter{err: errPipe}, WithErrorMapper(func(err error) error {
+		if err == errPipe {
+			return fmt.Errorf("%w: %v", errDisconnected, err)
+		}
+		return err
+	}))
+	fmt.Fprint(w, "foo")
+	if !errors.Is(w.Err(), errDisconnected) {
+		t.Errorf("expected %v, got: %v", errDisconnected, w.Err())
+	}
+}
+
+func TestErrorMapperNil(t *testing.T) {
+	w := NewAggregatedWriter(&errWriter{err: errors.New("ignored")}, WithErrorMapper(func(error) error {
+		return nil
+	}))
+	fmt.Fprint(w, "foo")
+	fatalOn(t, w.Err())
+}
+
+// flakyWriter fails every other write.
+type flakyWriter struct {
+	bytes.Buffer
+	calls int
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.calls%2 == 0 {
+		return 0, fmt.Errorf("write %d failed", w.calls)
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestContinueOnError(t *testing.T) {
+	b := &flakyWriter{}
+	w := NewAggregatedWriter(b, WithContinueOnError())
+	for _, s := range []string{"foo", "bar", "baz", "qux", "quux"} {
+		fmt.Fprint(w, s)
+	}
+	assertString(t, "foobazquux", b.String())
+	assertInt64(t, 10, w.N())
+	assertInt64(t, 3, w.WriteCount())
+	if w.Err() == nil || w.Err().Error() != "write 4 failed" {
+		t.Errorf("expected most recent error, got: %v", w.Err())
+	}
+
+	fatalOn(t, w.Close())
+	if _, err := fmt.Fprint(w, "foo"); err == nil {
+		t.Errorf("expected write after close to fail")
+	}
+}
+
+func TestClearErr(t *testing.T) {
+	var firstBytes int
+	b := &toggleWriter{}
+	w := NewAggregatedWriter(b, WithFirstByteHook(func(time.Time) { firstBytes++ }))
+	fmt.Fprint(w, "foo")
+	b.err = errors.New("broken")
+	fmt.Fprint(w, "bar")
+	b.err = nil
+
+	w.ClearErr()
+	fatalOn(t, w.Err())
+	assertInt64(t, 1, w.WriteCount())
+	assertInt64(t, 3, w.N())
+	fmt.Fprint(w, "baz")
+	fatalOn(t, w.Err())
+	assertInt64(t, 2, w.WriteCount())
+	assertInt64(t, 6, w.N())
+	assertInt64(t, 1, int64(firstBytes))
+	assertString(t, "foobaz", b.String())
+}
+
+func TestResetError(t *testing.T) {
+	degraded := errors.New("degraded")
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	stringify(w, testInput)
+	w.ResetError(degraded)
+	if w.Err() != degraded {
+		t.Errorf("expected %v, got: %v", degraded, w.Err())
+	}
+	assertInt64(t, testOutputLength, w.N())
+	assertInt64(t, int64(2*len(testInput)+1), w.WriteCount())
+}
+
+// scriptedWriter returns each of errs in turn, writing to its buffer on nil.
+type scriptedWriter struct {
+	bytes.Buffer
+	errs []error
+}
+
+func (w *scriptedWriter) Write(p []byte) (int, error) {
+	var err error
+	if len(w.errs) > 0 {
+		err, w.errs = w.errs[0], w.errs[1:]
+	}
+	if err != nil {
+		return 0, err
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestCollectAllDeduplicates(t *testing.T) {
+	errPipe := errors.New("broken pipe")
+	errTimeout := errors.New("timeout")
+	b := &scriptedWriter{errs: []error{errPipe, nil, errPipe, errTimeout, errPipe, nil}}
+	w := NewAggregatedWriter(b, WithErrorPolicy(CollectAll))
+	for _, s := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		fmt.Fprint(w, s)
+	}
+	assertString(t, "bfg", b.String())
+	assertInt64(t, 3, w.N())
+
+	counts := w.ErrorCounts()
+	assertInt64(t, 2, int64(len(counts)))
+	assertInt64(t, 3, int64(counts["broken pipe"]))
+	assertInt64(t, 1, int64(counts["timeout"]))
+
+	err := w.Err()
+	assertString(t, "broken pipe (3 times)\ntimeout", err.Error())
+	if !errors.Is(err, errPipe) || !errors.Is(err, errTimeout) {
+		t.Errorf("expected joined errors, got: %v", err)
+	}
+}
+
+func TestCollectAllOffsets(t *testing.T) {
+	errPipe := errors.New("broken pipe")
+	errTimeout := errors.New("timeout")
+	b := &scriptedWriter{errs: []error{nil, errPipe, nil, nil, errTimeout, errPipe}}
+	w := NewAggregatedWriter(b, WithErrorPolicy(CollectAll))
+	stringify(w, testInput)
+	assertInt64(t, 9, w.N())
+
+	errs := w.Errors()
+	assertInt64(t, 2, int64(len(errs)))
+	for i, expect := range []WriteError{{1, errPipe}, {8, errTimeout}} {
+		if *errs[i] != expect {
+			t.Errorf("expected %+v, got: %+v", expect, *errs[i])
+		}
+	}
+
+	var we *WriteError
+	if !errors.As(w.Err(), &we) || we.Offset != 1 {
+		t.Errorf("expected a *WriteError at offset 1, got: %v", we)
+	}
+	assertInt64(t, 0, int64(len(NewAggregatedWriter(b).Errors())))
+}
+
+func TestCollectAllBounded(t *testing.T) {
+	w := NewAggregatedWriter(&flakyWriter{}, WithErrorPolicy(CollectAll))
+	for i := 0; i < 4*maxDistinctErrors; i++ {
+		fmt.Fprint(w, "foo")
+	}
+	assertInt64(t, maxDistinctErrors, int64(len(w.ErrorCounts())))
+	if msg := w.Err().Error(); !strings.HasSuffix(msg, fmt.Sprintf("\n%d more errors", maxDistinctErrors)) {
+		t.Errorf("expected count of dropped errors, got: %v", msg)
+	}
+
+	w.ClearErr()
+	fatalOn(t, w.Err())
+	assertInt64(t, 0, int64(len(w.ErrorCounts())))
+}
+
+func TestMaxErrorLength(t *testing.T) {
+	expect := errors.New("write failed: " + strings.Repeat("x", 1000))
+	w := NewAggregatedWriter(&errWriter{err: expect}, WithMaxErrorLength(12))
+	fmt.Fprint(w, "foo")
+	err := w.Err()
+	assertString(t, "write failed... (1014 characters)", err.Error())
+	if !errors.Is(err, expect) {
+		t.Errorf("expected %v to wrap the original error", err)
+	}
+
+	w = NewAggregatedWriter(&errWriter{err: io.ErrUnexpectedEOF}, WithMaxErrorLength(100))
+	fmt.Fprint(w, "foo")
+	if w.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v, got: %v", io.ErrUnexpectedEOF, w.Err())
+	}
+}
@@ -0,0 +1,126 @@
+package demo
+
+import "io"
+
+// FailoverAggregatedWriter writes to the first of several writers and, when
+// it fails, fails over to the next. Write only fails once every writer has
+// failed.
+type FailoverAggregatedWriter struct {
+	*AggregatedWriter
+	f *failover
+}
+
+// FailoverOption configures a FailoverAggregatedWriter.
+type FailoverOption func(*failover)
+
+// WithReplayBuffer configures the writer to remember the last size bytes
+// written and replay them to each writer it fails over to, before resuming
+// with new writes. Because the failed writer may have accepted some of
+// those bytes, delivery is at-least-once: the stream written across all
+// writers may contain duplicates.
+func WithReplayBuffer(size int) FailoverOption {
+	return func(f *failover) { f.size = size }
+}
+
+// NewFailoverAggregatedWriter returns a FailoverAggregatedWriter that writes
+// to each of ws in turn.
+func NewFailoverAggregatedWriter(ws []io.Writer, opts ...FailoverOption) *FailoverAggregatedWriter {
+	f := &failover{ws: ws}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return &FailoverAggregatedWriter{AggregatedWriter: &AggregatedWriter{w: f}, f: f}
+}
+
+// Active returns the index of the writer currently written to.
+func (w *FailoverAggregatedWriter) Active() int { return w.f.active }
+
+// ReplayedBytes returns the number of bytes replayed to writers after
+// failing over. They are not included in N.
+func (w *FailoverAggregatedWriter) ReplayedBytes() int64 { return w.f.replayed }
+
+// failover writes to the first of ws that has not failed.
+type failover struct {
+	ws       []io.Writer
+	active   int
+	size     int
+	recent   []byte // up to size of the most recently written bytes
+	replayed int64
+}
+
+func (f *failover) Write(p []byte) (n int, err error) {
+	if len(f.ws) == 0 {
+		return 0, io.ErrClosedPipe
+	}
+	for {
+		nn, err := writeFull(f.ws[f.active], p[n:])
+		if nn < 0 || nn > len(p)-n {
+			nn, err = clampN(nn, 0, len(p)-n), ErrBadWriteCount
+		}
+		f.remember(p[n : n+nn])
+		n += nn
+		if err == nil {
+			return n, nil
+		}
+		if err = f.next(err); err != nil {
+			return n, err
+		}
+	}
+}
+
+// next fails over to the next writer that accepts the replay buffer, or
+// returns err if there are none left.
+func (f *failover) next(err error) error {
+	for f.active+1 < len(f.ws) {
+		f.active++
+		if len(f.recent) == 0 {
+			return nil
+		}
+		var n int
+		n, err = writeFull(f.ws[f.active], f.recent)
+		if n < 0 || n > len(f.recent) {
+			n, err = clampN(n, 0, len(f.recent)), ErrBadWriteCount
+		}
+		f.replayed += int64(n)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (f *failover) remember(p []byte) {
+	if f.size <= 0 {
+		return
+	}
+	f.recent = append(f.recent, p...)
+	if len(f.recent) > f.size {
+		n := copy(f.recent, f.recent[len(f.recent)-f.size:])
+		f.recent = f.recent[:n]
+	}
+}
+
+// Flush flushes the active writer, if it supports flushing.
+func (f *failover) Flush() error {
+	if len(f.ws) == 0 {
+		return nil
+	}
+	switch fl := f.ws[f.active].(type) {
+	case interface{ Flush() error }:
+		return fl.Flush()
+	case interface{ Flush() }:
+		fl.Flush()
+	}
+	return nil
+}
+
+// Close closes every writer that implements io.Closer.
+func (f *failover) Close() error {
+	errs := make([]error, len(f.ws))
+	for i, w := range f.ws {
+		if c, ok := w.(io.Closer); ok {
+			errs[i] = c.Close()
+		}
+	}
+	return joinErrors(errs...)
+}
@@ -0,0 +1,72 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestFailover(t *testing.T) {
+	primary := &toggleWriter{}
+	secondary := &bytes.Buffer{}
+	w := NewFailoverAggregatedWriter([]io.Writer{primary, secondary})
+	fmt.Fprint(w, "foo")
+	primary.err = errors.New("primary down")
+	fmt.Fprint(w, "bar")
+	fmt.Fprint(w, "baz")
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, 9, n)
+	assertInt64(t, 1, int64(w.Active()))
+	assertString(t, "foo", primary.String())
+	assertString(t, "barbaz", secondary.String())
+	assertInt64(t, 0, w.ReplayedBytes())
+}
+
+func TestFailoverReplayBuffer(t *testing.T) {
+	primary := &errWriter{n: 10, err: errors.New("primary down")}
+	secondary := &bytes.Buffer{}
+	w := NewFailoverAggregatedWriter([]io.Writer{primary, secondary}, WithReplayBuffer(8))
+	stringify(w, testInput)
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertInt64(t, 8, w.ReplayedBytes())
+	assertString(t, testOutput[2:10]+testOutput[10:], secondary.String())
+}
+
+func TestFailoverExhausted(t *testing.T) {
+	expect := errors.New("secondary down")
+	w := NewFailoverAggregatedWriter([]io.Writer{
+		&errWriter{n: 2, err: errors.New("primary down")},
+		&errWriter{err: expect},
+	}, WithReplayBuffer(8))
+	n, err := fmt.Fprint(w, "foo")
+	assertInt64(t, 2, int64(n))
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+}
+
+func TestFailoverBadWriteCount(t *testing.T) {
+	secondary := &bytes.Buffer{}
+	w := NewFailoverAggregatedWriter([]io.Writer{overcountWriter{}, secondary}, WithReplayBuffer(8))
+	fmt.Fprint(w, "foo")
+	fatalOn(t, w.Err())
+	assertInt64(t, 3, w.N())
+	assertInt64(t, 1, int64(w.Active()))
+	assertInt64(t, 3, w.ReplayedBytes())
+	assertString(t, "foo", secondary.String())
+
+	w = NewFailoverAggregatedWriter([]io.Writer{overcountWriter{}}, WithReplayBuffer(8))
+	if _, err := fmt.Fprint(w, "foo"); err != ErrBadWriteCount {
+		t.Errorf("expected %v, got: %v", ErrBadWriteCount, err)
+	}
+}
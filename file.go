@@ -0,0 +1,28 @@
+package demo
+
+import "os"
+
+// WriteToFile creates or truncates the named file, calls fn to write its
+// content and then flushes, optionally syncs, and closes the file. It returns
+// the number of bytes written and the first error from any stage.
+func WriteToFile(path string, perm os.FileMode, sync bool, fn func(w *AggregatedWriter) error) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+	w := NewAggregatedWriter(f)
+	err = fn(w)
+	if err == nil {
+		err = w.Err()
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && sync {
+		err = w.Sync()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return w.N(), err
+}
@@ -0,0 +1,43 @@
+package demo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, sync := range []bool{false, true} {
+		path := filepath.Join(dir, "output.json")
+		n, err := WriteToFile(path, 0644, sync, func(w *AggregatedWriter) error {
+			stringify(w, testInput)
+			return nil
+		})
+		fatalOn(t, err)
+		assertInt64(t, testOutputLength, n)
+
+		b, err := ioutil.ReadFile(path)
+		fatalOn(t, err)
+		assertString(t, testOutput, string(b))
+	}
+}
+
+func TestWriteToFileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+
+	expect := errors.New("render failed")
+	_, err = WriteToFile(filepath.Join(dir, "output.json"), 0644, true, func(w *AggregatedWriter) error {
+		return expect
+	})
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+}
@@ -0,0 +1,8 @@
+package demo
+
+// WithFlushPredicate configures w to flush the underlying writer, if it
+// supports flushing, after each successful write for which should returns
+// true. should is given the bytes passed to Write and the total written.
+func WithFlushPredicate(should func(lastWrite []byte, total int64) bool) Option {
+	return func(w *AggregatedWriter) { w.shouldFlush = should }
+}
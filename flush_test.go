@@ -0,0 +1,53 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// flushSpy records the buffer contents at each flush.
+type flushSpy struct {
+	bytes.Buffer
+	flushes []string
+}
+
+func (f *flushSpy) Flush() error {
+	f.flushes = append(f.flushes, f.String())
+	return nil
+}
+
+// failingFlusher fails every flush.
+type failingFlusher struct {
+	closeCounter
+	err error
+}
+
+func (f *failingFlusher) Flush() error { return f.err }
+
+func TestCloseFlushError(t *testing.T) {
+	b := &failingFlusher{err: errors.New("flush failed")}
+	w := NewAggregatedWriter(b)
+	fmt.Fprint(w, "foo")
+	if err := w.Close(); err != b.err {
+		t.Errorf("expected %v, got: %v", b.err, err)
+	}
+	assertInt64(t, 1, int64(b.closed))
+	if err := w.Err(); err != b.err {
+		t.Errorf("expected %v, got: %v", b.err, err)
+	}
+}
+
+func TestFlushPredicate(t *testing.T) {
+	b := &flushSpy{}
+	w := NewAggregatedWriter(b, WithFlushPredicate(func(p []byte, total int64) bool {
+		return bytes.HasSuffix(p, []byte("}"))
+	}))
+	for _, s := range []string{`{"a":`, `1}`, `{"b":2}`, `{"c":`} {
+		fmt.Fprint(w, s)
+	}
+	assertString(t, `{"a":1}|{"a":1}{"b":2}`, strings.Join(b.flushes, "|"))
+	assertInt64(t, 5, w.SinceFlush())
+}
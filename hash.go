@@ -0,0 +1,45 @@
+package demo
+
+import (
+	"encoding/hex"
+	"hash"
+)
+
+// WithHashes configures w to feed every byte accepted by the underlying
+// writer into each of hs.
+func WithHashes(hs ...hash.Hash) Option {
+	return func(w *AggregatedWriter) { w.hashes = append(w.hashes, hs...) }
+}
+
+// WithHash configures w to feed every byte accepted by the underlying
+// writer into h, whose digest is returned by Sum and SumHex. Because only
+// accepted bytes are hashed, the digest always matches N, even after a
+// short write.
+func WithHash(h hash.Hash) Option { return WithHashes(h) }
+
+// Sums returns the current digest of each hash configured with WithHashes,
+// in the order they were given.
+func (w *AggregatedWriter) Sums() [][]byte {
+	w.lock()
+	defer w.unlock()
+	sums := make([][]byte, len(w.hashes))
+	for i, h := range w.hashes {
+		sums[i] = h.Sum(nil)
+	}
+	return sums
+}
+
+// Sum returns the current digest of the first hash configured with WithHash
+// or WithHashes, or nil if there is none.
+func (w *AggregatedWriter) Sum() []byte {
+	w.lock()
+	defer w.unlock()
+	if len(w.hashes) == 0 {
+		return nil
+	}
+	return w.hashes[0].Sum(nil)
+}
+
+// SumHex returns Sum encoded as lowercase hexadecimal, as used by ETags and
+// checksum files.
+func (w *AggregatedWriter) SumHex() string { return hex.EncodeToString(w.Sum()) }
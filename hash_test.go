@@ -0,0 +1,41 @@
+package demo
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHashes(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithHashes(sha256.New(), md5.New()))
+	stringify(w, testInput)
+	sums := w.Sums()
+	assertInt64(t, 2, int64(len(sums)))
+	sha := sha256.Sum256([]byte(testOutput))
+	if !bytes.Equal(sha[:], sums[0]) {
+		t.Errorf("expected %x, got: %x", sha, sums[0])
+	}
+	md := md5.Sum([]byte(testOutput))
+	if !bytes.Equal(md[:], sums[1]) {
+		t.Errorf("expected %x, got: %x", md, sums[1])
+	}
+}
+
+func TestHash(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	if w.Sum() != nil || w.SumHex() != "" {
+		t.Errorf("expected no digest without a hash")
+	}
+
+	w = NewAggregatedWriter(&errWriter{n: 8, err: errors.New("broken")}, WithHash(sha256.New()))
+	stringify(w, testInput)
+	assertInt64(t, 8, w.N())
+	sha := sha256.Sum256([]byte(testOutput[:8]))
+	if !bytes.Equal(sha[:], w.Sum()) {
+		t.Errorf("expected %x, got: %x", sha, w.Sum())
+	}
+	assertString(t, fmt.Sprintf("%x", sha), w.SumHex())
+}
@@ -0,0 +1,102 @@
+package demo
+
+import (
+	"sync"
+	"time"
+)
+
+// WithHeartbeat configures w to write payload to the underlying writer
+// whenever nothing has been written for interval, to keep an idle
+// connection alive. Heartbeats are written from a background goroutine that
+// runs until Close, so w must be closed to release it. Heartbeats are
+// encrypted, recovered from panics and have their errors mapped like other
+// writes, but they are counted by HeartbeatBytes rather than N and are not
+// seen by observers. A heartbeat that fails stores its error like any other
+// write.
+func WithHeartbeat(interval time.Duration, payload []byte) Option {
+	return func(w *AggregatedWriter) {
+		w.locking = true
+		w.heartbeat = &heartbeat{interval: interval, payload: payload}
+	}
+}
+
+// HeartbeatBytes returns the number of heartbeat bytes written to the
+// underlying writer.
+func (w *AggregatedWriter) HeartbeatBytes() int64 {
+	w.lock()
+	defer w.unlock()
+	if w.heartbeat == nil {
+		return 0
+	}
+	return w.heartbeat.n
+}
+
+type heartbeat struct {
+	interval time.Duration
+	payload  []byte
+	n        int64
+	last     time.Time // the last write to the underlying writer
+
+	mu   sync.Mutex // serializes start and stop, such as concurrent calls to Close
+	quit chan struct{}
+	done chan struct{}
+}
+
+// start starts the goroutine that writes heartbeats to w.
+func (h *heartbeat) start(w *AggregatedWriter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = w.now()
+	h.quit, h.done = make(chan struct{}), make(chan struct{})
+	go h.run(w)
+}
+
+// stop stops the goroutine started by start and waits for it to return.
+// It must not be called with w locked.
+func (h *heartbeat) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.quit == nil {
+		return
+	}
+	close(h.quit)
+	<-h.done
+	h.quit = nil
+}
+
+func (h *heartbeat) run(w *AggregatedWriter) {
+	defer close(h.done)
+	t := w.getClock().NewTimer(h.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-h.quit:
+			return
+		case <-t.C():
+		}
+		t.Reset(h.beat(w))
+	}
+}
+
+// beat writes a heartbeat if w has been idle for the interval, and returns
+// the time until the next one is due.
+func (h *heartbeat) beat(w *AggregatedWriter) time.Duration {
+	w.lock()
+	defer w.unlock()
+	if idle := w.now().Sub(h.last); idle < h.interval {
+		return h.interval - idle
+	}
+	if w.w != nil && !w.closed && w.err == nil {
+		p := h.payload
+		if w.cipher != nil {
+			p = w.encrypt(p)
+		}
+		n, err := w.sinkWrite(p)
+		h.n += int64(clampN(n, 0, len(p)))
+		if err != nil {
+			w.fail(err)
+		}
+	}
+	h.last = w.now()
+	return h.interval
+}
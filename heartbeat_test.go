@@ -0,0 +1,103 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testHeartbeatInterval = 10 * time.Second
+
+// newHeartbeatWriter returns a writer with a heartbeat driven by clock,
+// once its timer is armed.
+func newHeartbeatWriter(t *testing.T, clock *fakeClock, b io.Writer, opts ...Option) *AggregatedWriter {
+	opts = append(opts, WithClock(clock), WithHeartbeat(testHeartbeatInterval, []byte("\n")))
+	w := NewAggregatedWriter(b, opts...)
+	clock.awaitTimer(t)
+	return w
+}
+
+// tick advances clock by d and waits for the heartbeat to re-arm.
+func tick(t *testing.T, clock *fakeClock, d time.Duration) {
+	clock.Advance(d)
+	clock.awaitTimer(t)
+}
+
+func TestHeartbeat(t *testing.T) {
+	clock := newFakeClock()
+	b := &bytes.Buffer{}
+	w := newHeartbeatWriter(t, clock, b)
+	for i := 0; i < 3; i++ {
+		tick(t, clock, testHeartbeatInterval)
+	}
+	assertInt64(t, 3, w.HeartbeatBytes())
+	fatalOn(t, w.Close())
+
+	clock.Advance(3 * testHeartbeatInterval)
+	assertInt64(t, 3, w.HeartbeatBytes())
+	assertInt64(t, 0, w.N())
+	assertString(t, "\n\n\n", b.String())
+}
+
+func TestHeartbeatResetByWrites(t *testing.T) {
+	clock := newFakeClock()
+	b := &bytes.Buffer{}
+	w := newHeartbeatWriter(t, clock, b)
+
+	// A write half way through the interval defers the heartbeat until a
+	// full interval has passed without writes.
+	clock.Advance(testHeartbeatInterval / 2)
+	w.Write([]byte("foo"))
+	tick(t, clock, testHeartbeatInterval/2)
+	assertInt64(t, 0, w.HeartbeatBytes())
+
+	tick(t, clock, testHeartbeatInterval/2)
+	assertInt64(t, 1, w.HeartbeatBytes())
+	fatalOn(t, w.Close())
+	assertInt64(t, 3, w.N())
+	assertString(t, "foo\n", b.String())
+}
+
+func TestHeartbeatRecoversPanics(t *testing.T) {
+	clock := newFakeClock()
+	w := newHeartbeatWriter(t, clock, panicWriter{}, WithPanicRecovery())
+	tick(t, clock, testHeartbeatInterval)
+	if err := w.Err(); !errors.Is(err, ErrWriterPanic) {
+		t.Errorf("expected %v, got: %v", ErrWriterPanic, err)
+	}
+	w.Close()
+}
+
+func TestHeartbeatMapsErrors(t *testing.T) {
+	clock := newFakeClock()
+	expect := errors.New("connection lost")
+	w := newHeartbeatWriter(t, clock, &errWriter{err: errors.New("broken")}, WithErrorMapper(func(error) error {
+		return expect
+	}))
+	tick(t, clock, testHeartbeatInterval)
+	if err := w.Err(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	w.Close()
+}
+
+func TestHeartbeatConcurrentClose(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		clock := newFakeClock()
+		b := &closeCounter{}
+		w := newHeartbeatWriter(t, clock, b, WithLocking())
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.Close()
+			}()
+		}
+		wg.Wait()
+		assertInt64(t, 1, int64(b.closed))
+	}
+}
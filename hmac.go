@@ -0,0 +1,44 @@
+package demo
+
+import (
+	"crypto/hmac"
+	"hash"
+)
+
+// WithHMAC configures w to compute an HMAC, using the hash function h and
+// key, of the bytes accepted by the underlying writer. The MAC is written to
+// the underlying writer by Close, after any trailer, unless an earlier write
+// failed. MAC bytes are reported by MACBytes rather than N.
+func WithHMAC(key []byte, h func() hash.Hash) Option {
+	return func(w *AggregatedWriter) { w.mac = hmac.New(h, key) }
+}
+
+// MAC returns the HMAC of the bytes written so far, or nil if WithHMAC was
+// not given.
+func (w *AggregatedWriter) MAC() []byte {
+	w.lock()
+	defer w.unlock()
+	if w.mac == nil {
+		return nil
+	}
+	return w.mac.Sum(nil)
+}
+
+// MACBytes returns the number of MAC bytes written by Close.
+func (w *AggregatedWriter) MACBytes() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.macN
+}
+
+func (w *AggregatedWriter) writeMAC() error {
+	if w.mac == nil || w.err != nil {
+		return nil
+	}
+	n, err := w.writeFooter(w.mac.Sum(nil))
+	w.macN += int64(n)
+	if err != nil {
+		w.latch(err)
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package demo
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestHMAC(t *testing.T) {
+	key := []byte("key")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(testOutput))
+	expect := mac.Sum(nil)
+
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithHMAC(key, sha256.New))
+	stringify(w, testInput)
+	if !hmac.Equal(expect, w.MAC()) {
+		t.Errorf("expected %x, got: %x", expect, w.MAC())
+	}
+	fatalOn(t, w.Close())
+
+	assertInt64(t, testOutputLength, w.N())
+	assertInt64(t, int64(len(expect)), w.MACBytes())
+	assertString(t, testOutput+string(expect), b.String())
+}
+
+func TestHMACError(t *testing.T) {
+	expect := errors.New("disk full")
+	b := &scriptedCloser{scriptedWriter: scriptedWriter{errs: []error{nil, expect}}}
+	w := NewAggregatedWriter(b, WithHMAC([]byte("key"), sha256.New))
+	fmt.Fprint(w, "foo")
+	if err := w.Close(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	assertInt64(t, 1, int64(b.closed))
+	assertInt64(t, 0, w.MACBytes())
+}
+
+func TestHMACShortWrite(t *testing.T) {
+	w := NewAggregatedWriter(&errWriter{n: 10}, WithHMAC([]byte("key"), sha256.New))
+	if err := w.Close(); err != io.ErrShortWrite {
+		t.Errorf("expected %v, got: %v", io.ErrShortWrite, err)
+	}
+	assertInt64(t, 10, w.MACBytes())
+	if w.Err() != io.ErrShortWrite {
+		t.Errorf("expected %v, got: %v", io.ErrShortWrite, w.Err())
+	}
+}
+
+func TestHMACBadWriteCount(t *testing.T) {
+	w := NewAggregatedWriter(overcountWriter{}, WithHMAC([]byte("key"), sha256.New))
+	if err := w.Close(); err != ErrBadWriteCount {
+		t.Errorf("expected %v, got: %v", ErrBadWriteCount, err)
+	}
+	assertInt64(t, sha256.Size, w.MACBytes())
+}
@@ -0,0 +1,24 @@
+package demo
+
+import "time"
+
+// WithFirstByteHook configures fn to be called once, when the first byte is
+// accepted by the underlying writer. It is useful for measuring
+// time-to-first-byte.
+func WithFirstByteHook(fn func(at time.Time)) Option {
+	return func(w *AggregatedWriter) { w.firstByte = fn }
+}
+
+// WithOnWrite configures fn to be called after each successful call to
+// Write, with the number of bytes written and N, for example to report
+// progress.
+func WithOnWrite(fn func(n, total int64)) Option {
+	return func(w *AggregatedWriter) { w.onWrite = fn }
+}
+
+// WithOnError configures fn to be called with the first error stored by w,
+// whether from a write, a flush or Close. After ClearErr, it is called again
+// for the next error.
+func WithOnError(fn func(err error)) Option {
+	return func(w *AggregatedWriter) { w.onError = fn }
+}
@@ -0,0 +1,78 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFirstByteHook(t *testing.T) {
+	var calls int
+	var at time.Time
+	clock := newFakeClock()
+	clock.Advance(time.Hour)
+	expect := clock.Now()
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithClock(clock), WithFirstByteHook(func(t time.Time) {
+		calls++
+		at = t
+	}))
+	w.Write(nil)
+	assertInt64(t, 0, int64(calls))
+	fmt.Fprint(w, "foo")
+	fmt.Fprint(w, "bar")
+	assertInt64(t, 1, int64(calls))
+	if !at.Equal(expect) {
+		t.Errorf("expected %v, got: %v", expect, at)
+	}
+}
+
+func TestFirstByteHookFailedWrites(t *testing.T) {
+	var calls int
+	w := NewAggregatedWriter(&errWriter{err: errors.New("broken")}, WithFirstByteHook(func(time.Time) {
+		calls++
+	}))
+	fmt.Fprint(w, "foo")
+	fmt.Fprint(w, "bar")
+	assertInt64(t, 0, int64(calls))
+}
+
+func TestOnWrite(t *testing.T) {
+	var progress []string
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithOnWrite(func(n, total int64) {
+		progress = append(progress, fmt.Sprintf("%d/%d", n, total))
+	}))
+	w.Write([]byte("foo"))
+	w.Write(nil)
+	w.Write([]byte("barbaz"))
+	assertString(t, "3/3 0/3 6/9", strings.Join(progress, " "))
+
+	w.Reset(&errWriter{n: 2, err: errors.New("broken")})
+	w.Write([]byte("foo"))
+	assertInt64(t, 3, int64(len(progress)))
+}
+
+func TestOnError(t *testing.T) {
+	var errs []error
+	expect := errors.New("broken")
+	w := NewAggregatedWriter(&errWriter{n: 3, err: expect}, WithContinueOnError(), WithOnError(func(err error) {
+		errs = append(errs, err)
+	}))
+	stringify(w, testInput)
+	w.Close()
+	if len(errs) != 1 || errs[0] != expect {
+		t.Fatalf("expected [%v], got: %v", expect, errs)
+	}
+
+	w = NewAggregatedWriter(&bytes.Buffer{}, WithOnError(func(err error) {
+		errs = append(errs, err)
+	}))
+	fatalOn(t, w.Close())
+	w.Write([]byte("foo"))
+	if len(errs) != 2 || errs[1] != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, errs)
+	}
+}
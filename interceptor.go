@@ -0,0 +1,47 @@
+package demo
+
+import "bytes"
+
+// WriteFunc writes p, with the same semantics as io.Writer.Write.
+type WriteFunc func(p []byte) (n int, err error)
+
+// Interceptor returns a WriteFunc that transforms its input before passing
+// it to next. The WriteFunc must report how many bytes of its own input were
+// written, not how many bytes it passed to next.
+type Interceptor func(next WriteFunc) WriteFunc
+
+// WithInterceptors configures w to pass each write through interceptors, in
+// the order given, before writing it to the underlying writer. N reports the
+// bytes written to the underlying writer after all transformations.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(w *AggregatedWriter) {
+		w.interceptors = append(w.interceptors, interceptors...)
+		w.chain = w.transform
+		for i := len(w.interceptors) - 1; i >= 0; i-- {
+			w.chain = w.interceptors[i](w.chain)
+		}
+	}
+}
+
+// PrefixInterceptor returns an Interceptor that writes prefix before each
+// write.
+func PrefixInterceptor(prefix []byte) Interceptor {
+	return func(next WriteFunc) WriteFunc {
+		return func(p []byte) (int, error) {
+			b := make([]byte, 0, len(prefix)+len(p))
+			n, err := next(append(append(b, prefix...), p...))
+			return clampN(n-len(prefix), 0, len(p)), err
+		}
+	}
+}
+
+// UppercaseInterceptor returns an Interceptor that converts ASCII and UTF-8
+// text to upper case.
+func UppercaseInterceptor() Interceptor {
+	return func(next WriteFunc) WriteFunc {
+		return func(p []byte) (int, error) {
+			n, err := next(bytes.ToUpper(p))
+			return clampN(n, 0, len(p)), err
+		}
+	}
+}
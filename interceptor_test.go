@@ -0,0 +1,32 @@
+package demo
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithInterceptors(
+		PrefixInterceptor([]byte("log: ")),
+		UppercaseInterceptor(),
+	))
+	n, err := fmt.Fprint(w, "foo")
+	fatalOn(t, err)
+	assertInt64(t, 3, int64(n))
+	fmt.Fprint(w, "bar")
+	assertString(t, "LOG: FOOLOG: BAR", b.String())
+	assertInt64(t, 16, w.N())
+}
+
+func TestInterceptorsOrder(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithInterceptors(
+		UppercaseInterceptor(),
+		PrefixInterceptor([]byte("log: ")),
+	), WithWrap(nil, []byte("\n")))
+	fmt.Fprint(w, "foo")
+	assertString(t, "log: FOO\n", b.String())
+	assertInt64(t, 9, w.N())
+}
@@ -0,0 +1,59 @@
+package demo
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONArrayWriter streams values to a writer as the elements of a JSON array.
+type JSONArrayWriter struct {
+	w       *AggregatedWriter
+	started bool
+	closed  bool
+}
+
+// NewJSONArrayWriter returns a JSONArrayWriter that writes to w. Its Result
+// counts only the bytes of the array, even if w is an AggregatedWriter.
+func NewJSONArrayWriter(w io.Writer) *JSONArrayWriter {
+	return &JSONArrayWriter{w: &AggregatedWriter{w: w, plain: true}}
+}
+
+// WriteElement writes the JSON encoding of v as the next element of the
+// array. A value that cannot be encoded fails the writer like a failed write.
+// WriteElement fails with io.ErrClosedPipe once the array has been closed.
+func (a *JSONArrayWriter) WriteElement(v interface{}) error {
+	if a.closed {
+		return io.ErrClosedPipe
+	}
+	if err := a.w.check(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return a.w.fail(err)
+	}
+	if a.started {
+		a.w.WriteString(", ")
+	} else {
+		a.w.WriteByte('[')
+		a.started = true
+	}
+	a.w.Write(b)
+	return a.w.Err()
+}
+
+// Close terminates the array. It does not close the underlying writer.
+func (a *JSONArrayWriter) Close() error {
+	if a.closed {
+		return a.w.Err()
+	}
+	a.closed = true
+	if !a.started {
+		a.w.WriteByte('[')
+	}
+	a.w.WriteByte(']')
+	return a.w.Err()
+}
+
+// Result returns the number of bytes written and the first error, if any.
+func (a *JSONArrayWriter) Result() (n int64, err error) { return a.w.Result() }
@@ -0,0 +1,66 @@
+package demo
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestJSONArrayWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewJSONArrayWriter(b)
+	for _, s := range testInput {
+		fatalOn(t, w.WriteElement(s))
+	}
+	fatalOn(t, w.Close())
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertString(t, testOutput, b.String())
+}
+
+func TestJSONArrayWriterEmpty(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewJSONArrayWriter(b)
+	fatalOn(t, w.Close())
+	assertString(t, "[]", b.String())
+}
+
+func TestJSONArrayWriterMarshalError(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewJSONArrayWriter(b)
+	fatalOn(t, w.WriteElement("foo"))
+	err := w.WriteElement(make(chan int))
+	if err == nil {
+		t.Fatal("expected a marshal error")
+	}
+	if w.WriteElement("bar") != err || w.Close() != err {
+		t.Errorf("expected sticky error %v", err)
+	}
+	assertString(t, `["foo"`, b.String())
+}
+
+func TestJSONArrayWriterClosed(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewJSONArrayWriter(b)
+	fatalOn(t, w.WriteElement("a"))
+	fatalOn(t, w.Close())
+	if err := w.WriteElement("b"); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	assertString(t, `["a"]`, b.String())
+}
+
+func TestJSONArrayWriterNested(t *testing.T) {
+	b := &bytes.Buffer{}
+	outer := NewAggregatedWriter(b)
+	outer.Write([]byte("prefix"))
+	w := NewJSONArrayWriter(outer)
+	fatalOn(t, w.WriteElement("a"))
+	fatalOn(t, w.Close())
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, 5, n)
+	assertInt64(t, 11, outer.N())
+	assertString(t, `prefix["a"]`, b.String())
+}
@@ -0,0 +1,70 @@
+package demo
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// latencySamples bounds the number of latencies kept for percentiles.
+const latencySamples = 1024
+
+// WithLatencyTracking configures w to time each call to the underlying
+// writer. Percentiles are computed from a uniform random sample of at most
+// 1024 calls.
+func WithLatencyTracking() Option {
+	return func(w *AggregatedWriter) {
+		w.latency = &latencies{rand: rand.New(rand.NewSource(1))}
+	}
+}
+
+// LatencyPercentiles returns the latency of calls to the underlying writer
+// at each of the given percentiles, from 0 to 100. It returns zero
+// durations if WithLatencyTracking was not given or nothing was written.
+func (w *AggregatedWriter) LatencyPercentiles(ps ...float64) []time.Duration {
+	w.lock()
+	defer w.unlock()
+	ds := make([]time.Duration, len(ps))
+	if w.latency == nil || len(w.latency.samples) == 0 {
+		return ds
+	}
+	sorted := make([]time.Duration, len(w.latency.samples))
+	copy(sorted, w.latency.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, p := range ps {
+		rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		ds[i] = sorted[clampN(rank, 0, len(sorted)-1)]
+	}
+	return ds
+}
+
+// MaxLatency returns the longest call to the underlying writer.
+func (w *AggregatedWriter) MaxLatency() time.Duration {
+	w.lock()
+	defer w.unlock()
+	if w.latency == nil {
+		return 0
+	}
+	return w.latency.max
+}
+
+// latencies keeps a reservoir sample of write latencies.
+type latencies struct {
+	rand    *rand.Rand
+	samples []time.Duration
+	seen    int64
+	max     time.Duration
+}
+
+func (l *latencies) add(d time.Duration) {
+	if d > l.max {
+		l.max = d
+	}
+	l.seen++
+	if len(l.samples) < latencySamples {
+		l.samples = append(l.samples, d)
+	} else if i := l.rand.Int63n(l.seen); i < latencySamples {
+		l.samples[i] = d
+	}
+}
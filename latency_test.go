@@ -0,0 +1,56 @@
+package demo
+
+import (
+	"testing"
+	"time"
+)
+
+// clockWriter advances a fake clock by the next of delays on each write.
+type clockWriter struct {
+	clock  *fakeClock
+	delays []time.Duration
+}
+
+func (w *clockWriter) Write(p []byte) (int, error) {
+	w.clock.Advance(w.delays[0])
+	w.delays = w.delays[1:]
+	return len(p), nil
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	clock := newFakeClock()
+	cw := &clockWriter{clock: clock}
+	for i := 1; i <= 100; i++ {
+		cw.delays = append(cw.delays, time.Duration(i)*time.Millisecond)
+	}
+	w := NewAggregatedWriter(cw, WithClock(clock), WithLatencyTracking())
+	for i := 0; i < 100; i++ {
+		w.Write([]byte("foo"))
+	}
+
+	ps := w.LatencyPercentiles(0, 50, 90, 99, 100)
+	for i, expect := range []time.Duration{1, 50, 90, 99, 100} {
+		if expect *= time.Millisecond; ps[i] != expect {
+			t.Errorf("expected %v, got: %v", expect, ps[i])
+		}
+	}
+	if max := w.MaxLatency(); max != 100*time.Millisecond {
+		t.Errorf("expected %v, got: %v", 100*time.Millisecond, max)
+	}
+}
+
+func TestLatencyReservoirBounded(t *testing.T) {
+	clock := newFakeClock()
+	cw := &clockWriter{clock: clock}
+	for i := 0; i < 4*latencySamples; i++ {
+		cw.delays = append(cw.delays, time.Duration(i%10+1)*time.Millisecond)
+	}
+	w := NewAggregatedWriter(cw, WithClock(clock), WithLatencyTracking())
+	for i := 0; i < 4*latencySamples; i++ {
+		w.Write([]byte("foo"))
+	}
+	assertInt64(t, latencySamples, int64(len(w.latency.samples)))
+	if p := w.LatencyPercentiles(50)[0]; p < 4*time.Millisecond || p > 7*time.Millisecond {
+		t.Errorf("expected median near 5ms, got: %v", p)
+	}
+}
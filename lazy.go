@@ -0,0 +1,31 @@
+package demo
+
+import "io"
+
+// NewLazyAggregatedWriter returns an AggregatedWriter that calls factory to
+// obtain its underlying writer when it is first written to, or when Close
+// writes a trailer or MAC. If factory fails, its error becomes the stored
+// error.
+func NewLazyAggregatedWriter(factory func() (io.Writer, error), opts ...Option) *AggregatedWriter {
+	ag := NewAggregatedWriter(nil, opts...)
+	ag.factory = factory
+	return ag
+}
+
+// ensureWriter resolves the underlying writer of a lazy writer that has
+// not yet been written to.
+func (w *AggregatedWriter) ensureWriter() error {
+	if w.w != nil || w.factory == nil {
+		return nil
+	}
+	return w.resolve()
+}
+
+func (w *AggregatedWriter) resolve() error {
+	uw, err := w.factory()
+	if err != nil {
+		return err
+	}
+	w.w, w.factory = uw, nil
+	return nil
+}
@@ -0,0 +1,98 @@
+package demo
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestLazyAggregatedWriter(t *testing.T) {
+	var calls int
+	b := &bytes.Buffer{}
+	w := NewLazyAggregatedWriter(func() (io.Writer, error) {
+		calls++
+		return b, nil
+	})
+	assertInt64(t, 0, int64(calls))
+	stringify(w, testInput)
+	assertInt64(t, 1, int64(calls))
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertString(t, testOutput, b.String())
+}
+
+func TestLazyAggregatedWriterFactoryError(t *testing.T) {
+	var calls int
+	expect := errors.New("connection refused")
+	w := NewLazyAggregatedWriter(func() (io.Writer, error) {
+		calls++
+		return nil, expect
+	})
+	stringify(w, testInput)
+	assertInt64(t, 1, int64(calls))
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+	fatalOn(t, w.Close())
+}
+
+func TestLazyAggregatedWriterUnused(t *testing.T) {
+	w := NewLazyAggregatedWriter(func() (io.Writer, error) {
+		t.Fatal("unexpected call to factory")
+		return nil, nil
+	})
+	fatalOn(t, w.Flush())
+	fatalOn(t, w.Close())
+	if _, err := fmt.Fprint(w, "foo"); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+}
+
+func TestLazyAggregatedWriterCloseWrites(t *testing.T) {
+	key := []byte("key")
+	mac := hmac.New(sha256.New, key)
+	sum := mac.Sum(nil)
+
+	for name, tt := range map[string]struct {
+		opt    Option
+		expect string
+	}{
+		"trailer": {WithTrailer(func(stats Stats) []byte {
+			return []byte(fmt.Sprintf("%d bytes", stats.Bytes))
+		}), "0 bytes"},
+		"hmac": {WithHMAC(key, sha256.New), string(sum)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			b := &bytes.Buffer{}
+			w := NewLazyAggregatedWriter(func() (io.Writer, error) {
+				calls++
+				return b, nil
+			}, tt.opt)
+			fatalOn(t, w.Close())
+			assertInt64(t, 1, int64(calls))
+			assertInt64(t, 0, w.N())
+			assertString(t, tt.expect, b.String())
+		})
+	}
+}
+
+func TestLazyAggregatedWriterCloseFactoryError(t *testing.T) {
+	expect := errors.New("connection refused")
+	w := NewLazyAggregatedWriter(func() (io.Writer, error) {
+		return nil, expect
+	}, WithHMAC([]byte("key"), sha256.New))
+	if err := w.Close(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+	assertInt64(t, 0, w.MACBytes())
+}
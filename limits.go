@@ -0,0 +1,144 @@
+package demo
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrWriteTooLarge is returned for a write larger than the size
+	// configured with WithMaxSingleWrite.
+	ErrWriteTooLarge = errors.New("demo: write too large")
+
+	// ErrLimitExceeded matches the *LimitError returned for a write that
+	// would take N past the limit configured with WithLimit.
+	ErrLimitExceeded = errors.New("demo: write limit exceeded")
+
+	// ErrTooManyWrites is returned for a write beyond the number configured
+	// with WithMaxWrites.
+	ErrTooManyWrites = errors.New("demo: too many writes")
+)
+
+// WithMaxSingleWrite configures w to reject, with ErrWriteTooLarge, any
+// write of more than max bytes without writing any of it. Unlike
+// WithMaxChunk, which splits large writes, this protects downstreams that
+// must never receive a frame above a hard limit.
+func WithMaxSingleWrite(max int) Option {
+	return func(w *AggregatedWriter) { w.maxSingleWrite = max }
+}
+
+// WithWriteSizeValidator configures fn to be called with the length of each
+// write before it is written. If fn returns an error, the write fails with
+// that error and nothing is written. fn sees the size of each call to Write,
+// not of any logical record.
+func WithWriteSizeValidator(fn func(size int) error) Option {
+	return func(w *AggregatedWriter) { w.validateSize = fn }
+}
+
+// WithLimit configures w to reject, with a *LimitError, any write that
+// would take N past limit, without writing any of it. Like N, the limit
+// counts the bytes that reach the underlying writer, including those added
+// by WithWrap and by interceptors.
+func WithLimit(limit int64) Option {
+	return func(w *AggregatedWriter) { w.limit, w.truncate = limit, false }
+}
+
+// WithTruncatingLimit is like WithLimit, except that a write that would
+// take N past limit writes as much as fits before failing, so that exactly
+// limit bytes are written. A write that interceptors take past limit is
+// rejected rather than truncated, since its transformed size is not known
+// in advance.
+func WithTruncatingLimit(limit int64) Option {
+	return func(w *AggregatedWriter) { w.limit, w.truncate = limit, true }
+}
+
+// LimitError is returned for a write that would exceed the limit configured
+// with WithLimit or WithTruncatingLimit. It matches ErrLimitExceeded.
+type LimitError struct {
+	Limit     int64 // the configured limit
+	Attempted int64 // N had the write succeeded in full
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %d bytes attempted, limit is %d", ErrLimitExceeded, e.Attempted, e.Limit)
+}
+
+func (e *LimitError) Is(target error) bool { return target == ErrLimitExceeded }
+
+// truncateToLimit returns as much of p as fits within a truncating limit and, if
+// that is not all of p, the *LimitError the write must fail with.
+func (w *AggregatedWriter) truncateToLimit(p []byte) ([]byte, error) {
+	attempted := w.n + int64(len(p)+w.overhead())
+	if !w.truncate || w.limit <= 0 || attempted <= w.limit {
+		return p, nil
+	}
+	room := w.limit - w.n - int64(w.overhead())
+	if room < 0 {
+		room = 0
+	}
+	return p[:room], &LimitError{Limit: w.limit, Attempted: attempted}
+}
+
+// WithMaxWrites configures w to reject, with ErrTooManyWrites, any write
+// after max calls to Write have succeeded.
+func WithMaxWrites(max int64) Option {
+	return func(w *AggregatedWriter) { w.maxWrites = max }
+}
+
+// Capacity is the headroom remaining under the limits of an
+// AggregatedWriter. Each field is -1 if the corresponding limit is not set.
+type Capacity struct {
+	BytesRemaining  int64 // the largest write that fits; see WithLimit
+	WritesRemaining int64 // see WithMaxWrites
+	MaxSingleWrite  int   // see WithMaxSingleWrite
+}
+
+// Capacity returns the headroom remaining under the limits of w, so that a
+// caller can check whether a write will fit before attempting it.
+func (w *AggregatedWriter) Capacity() Capacity {
+	w.lock()
+	defer w.unlock()
+	c := Capacity{BytesRemaining: -1, WritesRemaining: -1, MaxSingleWrite: -1}
+	if w.limit > 0 {
+		c.BytesRemaining = w.limit - w.n - int64(w.overhead())
+		if c.BytesRemaining < 0 {
+			c.BytesRemaining = 0
+		}
+	}
+	if w.maxWrites > 0 {
+		c.WritesRemaining = w.maxWrites - w.writes
+		if c.WritesRemaining < 0 {
+			c.WritesRemaining = 0
+		}
+	}
+	if w.maxSingleWrite > 0 {
+		c.MaxSingleWrite = w.maxSingleWrite
+	}
+	return c
+}
+
+// guard returns an error if p must not be written.
+func (w *AggregatedWriter) guard(p []byte) error {
+	if w.maxSingleWrite > 0 && len(p) > w.maxSingleWrite {
+		return ErrWriteTooLarge
+	}
+	if w.maxWrites > 0 && w.writes >= w.maxWrites {
+		return ErrTooManyWrites
+	}
+	if w.validateSize != nil {
+		return w.validateSize(len(p))
+	}
+	return nil
+}
+
+// checkLimit returns a *LimitError if writing size bytes to the underlying
+// writer would take N past the limit.
+func (w *AggregatedWriter) checkLimit(size int) error {
+	if w.limit <= 0 {
+		return nil
+	}
+	if attempted := w.n + int64(size); attempted > w.limit {
+		return &LimitError{Limit: w.limit, Attempted: attempted}
+	}
+	return nil
+}
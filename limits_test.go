@@ -0,0 +1,202 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMaxSingleWrite(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithMaxSingleWrite(4))
+	n, err := w.Write([]byte("foo!"))
+	fatalOn(t, err)
+	assertInt64(t, 4, int64(n))
+
+	n, err = w.Write([]byte("bar!!"))
+	assertInt64(t, 0, int64(n))
+	if err != ErrWriteTooLarge {
+		t.Errorf("expected %v, got: %v", ErrWriteTooLarge, err)
+	}
+	if w.Err() != ErrWriteTooLarge {
+		t.Errorf("expected %v, got: %v", ErrWriteTooLarge, w.Err())
+	}
+	assertString(t, "foo!", b.String())
+	assertInt64(t, 4, w.N())
+}
+
+func TestWriteSizeValidator(t *testing.T) {
+	errFrameSize := errors.New("frame must be 512 bytes")
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWriteSizeValidator(func(size int) error {
+		if size != 512 {
+			return errFrameSize
+		}
+		return nil
+	}))
+	n, err := w.Write(make([]byte, 512))
+	fatalOn(t, err)
+	assertInt64(t, 512, int64(n))
+
+	n, err = w.Write(make([]byte, 100))
+	assertInt64(t, 0, int64(n))
+	if err != errFrameSize {
+		t.Errorf("expected %v, got: %v", errFrameSize, err)
+	}
+	if w.Err() != errFrameSize {
+		t.Errorf("expected %v, got: %v", errFrameSize, w.Err())
+	}
+	assertInt64(t, 512, int64(b.Len()))
+	assertInt64(t, 512, w.N())
+}
+
+func TestLimit(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithLimit(8))
+	w.Write([]byte("foo"))
+	w.Write([]byte("bar"))
+	n, err := w.Write([]byte("baz"))
+	assertInt64(t, 0, int64(n))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected %v, got: %v", ErrLimitExceeded, err)
+	}
+	var le *LimitError
+	if !errors.As(err, &le) || *le != (LimitError{Limit: 8, Attempted: 9}) {
+		t.Errorf("expected limit 8 and 9 attempted, got: %v", err)
+	}
+	assertString(t, "demo: write limit exceeded: 9 bytes attempted, limit is 8", err.Error())
+	assertString(t, "foobar", b.String())
+	assertInt64(t, 6, w.N())
+}
+
+func TestMaxWrites(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithMaxWrites(2))
+	w.Write([]byte("foo"))
+	w.Write([]byte("bar"))
+	if _, err := w.Write([]byte("baz")); err != ErrTooManyWrites {
+		t.Errorf("expected %v, got: %v", ErrTooManyWrites, err)
+	}
+	assertInt64(t, 6, w.N())
+}
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct {
+		opts   []Option
+		expect Capacity
+	}{
+		{nil, Capacity{-1, -1, -1}},
+		{[]Option{WithLimit(100)}, Capacity{79, -1, -1}},
+		{[]Option{WithMaxWrites(10)}, Capacity{-1, 3, -1}},
+		{[]Option{WithMaxSingleWrite(512)}, Capacity{-1, -1, 512}},
+		{[]Option{WithLimit(21), WithMaxWrites(7), WithMaxSingleWrite(5)}, Capacity{0, 0, 5}},
+	} {
+		w := NewAggregatedWriter(&bytes.Buffer{}, tt.opts...)
+		stringify(w, testInput)
+		fatalOn(t, w.Err())
+		if c := w.Capacity(); c != tt.expect {
+			t.Errorf("expected %+v, got: %+v", tt.expect, c)
+		}
+	}
+}
+
+func TestTruncatingLimit(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithTruncatingLimit(8))
+	stringify(w, testInput)
+	n, err := w.Result()
+	assertInt64(t, 8, n)
+	var le *LimitError
+	if !errors.As(err, &le) || *le != (LimitError{Limit: 8, Attempted: 13}) {
+		t.Errorf("expected limit 8 and 13 attempted, got: %v", err)
+	}
+	assertString(t, testOutput[:8], b.String())
+	assertInt64(t, 0, w.Capacity().BytesRemaining)
+}
+
+func TestTruncatingLimitReached(t *testing.T) {
+	var calls int
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithTruncatingLimit(3), WithContinueOnError(),
+		WithOnWrite(func(n, total int64) { calls++ }))
+	_, err := w.Write([]byte("foo"))
+	fatalOn(t, err)
+	n, err := w.Write([]byte("bar"))
+	assertInt64(t, 0, int64(n))
+	var le *LimitError
+	if !errors.As(err, &le) || *le != (LimitError{Limit: 3, Attempted: 6}) {
+		t.Errorf("expected limit 3 and 6 attempted, got: %v", err)
+	}
+	assertInt64(t, 1, w.WriteCount())
+	assertInt64(t, 1, int64(calls))
+	assertString(t, "foo", b.String())
+}
+
+func TestTruncatingLimitWithToken(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithTruncatingLimit(3), WithWriteToken("s3cret"))
+	n, err := w.WriteWithToken("s3cret", []byte("foobar"))
+	assertInt64(t, 3, int64(n))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected %v, got: %v", ErrLimitExceeded, err)
+	}
+	assertInt64(t, 3, w.N())
+	assertString(t, "foo", b.String())
+}
+
+func TestLimitCountsTransformedBytes(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"wrap":        WithWrap([]byte("<<"), []byte(">>")),
+		"interceptor": WithInterceptors(PrefixInterceptor([]byte("<<<<"))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			w := NewAggregatedWriter(b, opt, WithLimit(5))
+			_, err := w.Write([]byte("abcde"))
+			var le *LimitError
+			if !errors.As(err, &le) || *le != (LimitError{Limit: 5, Attempted: 9}) {
+				t.Errorf("expected limit 5 and 9 attempted, got: %v", err)
+			}
+			assertInt64(t, 0, w.N())
+			assertString(t, "", b.String())
+		})
+	}
+
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWrap([]byte("<<"), []byte(">>")), WithLimit(9))
+	assertInt64(t, 5, w.Capacity().BytesRemaining)
+	_, err := w.Write([]byte("abcde"))
+	fatalOn(t, err)
+	assertInt64(t, 9, w.N())
+	assertInt64(t, 0, w.Capacity().BytesRemaining)
+	assertString(t, "<<abcde>>", b.String())
+}
+
+func TestTruncatingLimitWrap(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWrap([]byte("<"), []byte(">")), WithTruncatingLimit(5))
+	n, err := w.Write([]byte("abcde"))
+	assertInt64(t, 3, int64(n))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected %v, got: %v", ErrLimitExceeded, err)
+	}
+	assertInt64(t, 5, w.N())
+	assertString(t, "<abc>", b.String())
+}
+
+func TestTruncatingLimitCountsFailure(t *testing.T) {
+	var calls int
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithTruncatingLimit(3),
+		WithOnWrite(func(n, total int64) { calls++ }))
+	n, err := w.Write([]byte("foobar"))
+	assertInt64(t, 3, int64(n))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected %v, got: %v", ErrLimitExceeded, err)
+	}
+	stats := w.Stats()
+	assertInt64(t, 3, stats.Bytes)
+	assertInt64(t, 0, stats.Writes)
+	assertInt64(t, 1, stats.Errors)
+	assertInt64(t, 0, int64(calls))
+	assertString(t, "foo", b.String())
+}
@@ -0,0 +1,43 @@
+package demo
+
+import "bytes"
+
+// WithLineCallback configures fn to be called with each complete line
+// written to the underlying writer, excluding the trailing newline. Partial
+// lines are buffered across writes and delivered by Flush or Close. The slice
+// passed to fn is only valid for the duration of the call.
+func WithLineCallback(fn func(line []byte)) Option {
+	return func(w *AggregatedWriter) { w.lines = &lineSplitter{fn: fn} }
+}
+
+// lineSplitter reassembles newline-terminated lines from arbitrary chunks.
+type lineSplitter struct {
+	fn  func(line []byte)
+	buf []byte
+}
+
+func (s *lineSplitter) write(p []byte) {
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			s.buf = append(s.buf, p...)
+			return
+		}
+		if len(s.buf) == 0 {
+			s.fn(p[:i])
+		} else {
+			s.buf = append(s.buf, p[:i]...)
+			s.fn(s.buf)
+			s.buf = s.buf[:0]
+		}
+		p = p[i+1:]
+	}
+}
+
+func (s *lineSplitter) flush() {
+	if len(s.buf) == 0 {
+		return
+	}
+	s.fn(s.buf)
+	s.buf = s.buf[:0]
+}
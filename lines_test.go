@@ -0,0 +1,24 @@
+package demo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineCallback(t *testing.T) {
+	var lines []string
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithLineCallback(func(line []byte) {
+		lines = append(lines, string(line))
+	}))
+	for _, s := range []string{"fo", "o\nb", "ar\n", "\nba", "z"} {
+		fmt.Fprint(w, s)
+	}
+	assertString(t, "foo,bar,", strings.Join(lines, ","))
+	fatalOn(t, w.Close())
+	assertString(t, "foo,bar,,baz", strings.Join(lines, ","))
+	assertInt64(t, 12, w.N())
+	assertString(t, "foo\nbar\n\nbaz", b.String())
+}
@@ -0,0 +1,11 @@
+package demo
+
+// WithLocking makes w safe for concurrent use, so that several goroutines
+// can share one writer: writes are serialized by a mutex, and accessors
+// such as N, Err, Stats, Commit and DrainCapture may be called while writes
+// are in progress. Each call to Write is written whole, but the order of
+// concurrent writes is undefined. Locking costs an uncontended mutex per
+// call; see BenchmarkWriteLocking.
+func WithLocking() Option {
+	return func(w *AggregatedWriter) { w.locking = true }
+}
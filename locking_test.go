@@ -0,0 +1,131 @@
+package demo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithLocking(t *testing.T) {
+	const writers, writes = 8, 200
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithLocking())
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				w.Write([]byte("foo\n"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				w.N()
+				w.Err()
+				w.Result()
+				w.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, 4*writers*writes, n)
+	assertInt64(t, writers*writes, w.Stats().Writes)
+	assertString(t, strings.Repeat("foo\n", writers*writes), b.String())
+}
+
+func TestWithLockingAccessors(t *testing.T) {
+	const writers, writes = 4, 200
+	w := NewAggregatedWriter(&errWriter{n: 1000, err: errors.New("disk full")},
+		WithLocking(), WithErrorPolicy(CollectAll), WithLatencyTracking(),
+		WithThroughputWindow(time.Minute), WithExpectedFinalN(4*writers*writes),
+		WithInMemoryCapture(0), WithHMAC([]byte("key"), sha256.New), WithTees(ioutil.Discard),
+		WithRecordSize(8), WithBlockSize(512), WithLimit(1<<20))
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				w.Write([]byte("foo\n"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				w.ErrorCounts()
+				w.Throughput()
+				w.ETA()
+				w.LatencyPercentiles(50, 99)
+				w.MaxLatency()
+				w.Commit(w.N())
+				w.Committed()
+				w.Captured()
+				w.CaptureTruncated()
+				w.VerifyCapture(0)
+				w.DrainCapture(ioutil.Discard)
+				w.MAC()
+				w.WriteCount()
+				w.AvgWriteSize()
+				w.SinceFlush()
+				w.BilledN()
+				w.HighWaterMark()
+				w.Touched()
+				w.UnderlyingType()
+				w.TeeErrs()
+				w.Capacity()
+				w.RecordOffset()
+				w.RecordRemaining()
+				w.PadToRecord(' ')
+				w.TrailerBytes()
+				w.MACBytes()
+				w.Plaintext()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if w.ErrorCounts()["disk full"] == 0 {
+		t.Errorf("expected errors to be counted, got: %v", w.ErrorCounts())
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	p := []byte(testOutput)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		w.Write(p)
+	}
+}
+
+func BenchmarkWriteLocking(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard, WithLocking())
+	p := []byte(testOutput)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		w.Write(p)
+	}
+}
+
+func BenchmarkWriteLockingParallel(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard, WithLocking())
+	p := []byte(testOutput)
+	b.SetBytes(int64(len(p)))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w.Write(p)
+		}
+	})
+}
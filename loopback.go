@@ -0,0 +1,14 @@
+package demo
+
+import (
+	"bytes"
+	"io"
+)
+
+// NewLoopback returns an AggregatedWriter and a reader of everything written
+// to it, for round-trip tests. Written bytes are buffered in memory, so
+// neither side blocks; the reader returns io.EOF once it has caught up.
+func NewLoopback(opts ...Option) (*AggregatedWriter, io.Reader) {
+	b := &bytes.Buffer{}
+	return NewAggregatedWriter(b, opts...), b
+}
@@ -0,0 +1,18 @@
+package demo
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestLoopback(t *testing.T) {
+	w, r := NewLoopback()
+	stringify(w, testInput)
+	b, err := ioutil.ReadAll(r)
+	fatalOn(t, err)
+	assertString(t, testOutput, string(b))
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+}
@@ -0,0 +1,45 @@
+package demo
+
+// Metrics receives notifications of the activity of an AggregatedWriter, for
+// forwarding to a monitoring system.
+type Metrics interface {
+	// ObserveWrite is called after each call to Write that reaches the
+	// underlying writer.
+	ObserveWrite(bytes int, err error)
+
+	// ObserveClose is called once the AggregatedWriter is closed.
+	ObserveClose(stats Stats)
+}
+
+// WithMetrics configures w to notify m of its activity.
+func WithMetrics(m Metrics) Option {
+	return func(w *AggregatedWriter) { w.metrics = m }
+}
+
+// NopMetrics is a Metrics implementation that discards all notifications.
+// It may be embedded to implement only part of the interface.
+type NopMetrics struct{}
+
+func (NopMetrics) ObserveWrite(bytes int, err error) {}
+func (NopMetrics) ObserveClose(stats Stats)          {}
+
+// MemoryMetrics is a Metrics implementation that records notifications in
+// memory. It is not safe for concurrent use.
+type MemoryMetrics struct {
+	Writes int     // calls to ObserveWrite
+	Bytes  int64   // total bytes passed to ObserveWrite
+	Errors []error // non-nil errors passed to ObserveWrite
+	Closes []Stats // stats passed to ObserveClose
+}
+
+func (m *MemoryMetrics) ObserveWrite(bytes int, err error) {
+	m.Writes++
+	m.Bytes += int64(bytes)
+	if err != nil {
+		m.Errors = append(m.Errors, err)
+	}
+}
+
+func (m *MemoryMetrics) ObserveClose(stats Stats) {
+	m.Closes = append(m.Closes, stats)
+}
@@ -0,0 +1,50 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	expect := errors.New("broken")
+	m := &MemoryMetrics{}
+	w := NewAggregatedWriter(&errWriter{n: 10, err: expect}, WithMetrics(m))
+	stringify(w, testInput)
+	fatalOn(t, w.Close())
+
+	assertInt64(t, 4, int64(m.Writes))
+	assertInt64(t, 10, m.Bytes)
+	assertInt64(t, 1, int64(len(m.Errors)))
+	if m.Errors[0] != expect {
+		t.Errorf("expected %v, got: %v", expect, m.Errors[0])
+	}
+	assertInt64(t, 1, int64(len(m.Closes)))
+	stats := m.Closes[0]
+	assertInt64(t, 10, stats.Bytes)
+	assertInt64(t, 3, stats.Writes)
+	assertInt64(t, 1, stats.Errors)
+	if stats.Err != expect {
+		t.Errorf("expected %v, got: %v", expect, stats.Err)
+	}
+}
+
+var _ Metrics = NopMetrics{}
+
+func TestMetricsLimitRejected(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"wrap":        WithWrap([]byte("<<"), []byte(">>")),
+		"interceptor": WithInterceptors(PrefixInterceptor([]byte("<<<<"))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := &MemoryMetrics{}
+			w := NewAggregatedWriter(&bytes.Buffer{}, opt, WithLimit(5), WithMetrics(m))
+			if _, err := w.Write([]byte("abcde")); !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("expected %v, got: %v", ErrLimitExceeded, err)
+			}
+			assertInt64(t, 0, int64(m.Writes))
+			assertInt64(t, 0, w.Stats().Errors)
+			assertInt64(t, 0, w.Stats().Writes)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package demo
+
+import (
+	"io"
+	"sync"
+)
+
+// AggregatedMultiWriter duplicates its writes to several writers, like
+// io.MultiWriter. N reports the length of the payload written, not the sum
+// over all writers.
+type AggregatedMultiWriter struct {
+	*AggregatedWriter
+	fan *fanout
+}
+
+// MultiOption configures an AggregatedMultiWriter.
+type MultiOption func(*fanout)
+
+// WithConcurrentFanout configures the writer to write to up to maxParallel
+// writers at once. Each call to Write still completes on every writer before
+// returning, so the order of writes to any one writer is preserved. Errors
+// from every writer are joined.
+func WithConcurrentFanout(maxParallel int) MultiOption {
+	return func(f *fanout) { f.parallel = maxParallel }
+}
+
+// WithBestEffort configures the writer to keep writing to the remaining
+// writers after one fails, rather than failing the write. A failed writer is
+// not written to again, and its error is reported by Results and Result.
+// Writes fail only once every writer has failed.
+func WithBestEffort() MultiOption {
+	return func(f *fanout) { f.bestEffort = true }
+}
+
+// NewAggregatedMultiWriter returns an AggregatedMultiWriter that writes to
+// each of ws.
+func NewAggregatedMultiWriter(ws []io.Writer, opts ...MultiOption) *AggregatedMultiWriter {
+	fan := &fanout{ws: ws, ns: make([]int64, len(ws)), errs: make([]error, len(ws))}
+	for _, opt := range opts {
+		opt(fan)
+	}
+	return &AggregatedMultiWriter{AggregatedWriter: &AggregatedWriter{w: fan}, fan: fan}
+}
+
+// Writers returns the writers written to by w.
+func (w *AggregatedMultiWriter) Writers() []io.Writer {
+	ws := make([]io.Writer, len(w.fan.ws))
+	copy(ws, w.fan.ws)
+	return ws
+}
+
+// SinkN returns the number of bytes written to each writer, in the same
+// order as Writers. These may differ from each other, and from N, when a
+// writer fails.
+func (w *AggregatedMultiWriter) SinkN() []int64 {
+	ns := make([]int64, len(w.fan.ns))
+	copy(ns, w.fan.ns)
+	return ns
+}
+
+// WriterResult is the outcome of writing to one writer of an
+// AggregatedMultiWriter.
+type WriterResult struct {
+	N   int64 // bytes written
+	Err error // the first error, if any
+}
+
+// Results returns the bytes written to and first error from each writer, in
+// the same order as Writers.
+func (w *AggregatedMultiWriter) Results() []WriterResult {
+	rs := make([]WriterResult, len(w.fan.ws))
+	for i := range rs {
+		rs[i] = WriterResult{N: w.fan.ns[i], Err: w.fan.errs[i]}
+	}
+	return rs
+}
+
+// Result returns N and the stored error or, if there is none, the errors
+// of any writers that failed in best-effort mode.
+func (w *AggregatedMultiWriter) Result() (n int64, err error) {
+	n, err = w.AggregatedWriter.Result()
+	if err == nil {
+		err = joinErrors(w.fan.errs...)
+	}
+	return n, err
+}
+
+// fanout writes to several writers.
+type fanout struct {
+	ws         []io.Writer
+	ns         []int64 // bytes written to each of ws
+	errs       []error // first error from each of ws
+	parallel   int
+	bestEffort bool
+}
+
+// Write writes p to each writer in turn, stopping at the first failure, or
+// to all writers concurrently if configured. In best-effort mode, writers
+// that have failed are skipped and Write fails only if all have failed. It
+// returns the most bytes written to any one writer.
+func (f *fanout) Write(p []byte) (n int, err error) {
+	if f.parallel > 1 {
+		n, err = f.writeConcurrent(p)
+	} else {
+		n, err = f.writeSequential(p)
+	}
+	if f.bestEffort {
+		err = nil
+		if f.failed() {
+			err = joinErrors(f.errs...)
+		}
+	}
+	return n, err
+}
+
+func (f *fanout) writeSequential(p []byte) (n int, err error) {
+	for i, w := range f.ws {
+		if f.errs[i] != nil && f.bestEffort {
+			continue
+		}
+		nn, err := writeFull(w, p)
+		f.ns[i] += int64(nn)
+		if nn > n {
+			n = nn
+		}
+		if err != nil {
+			f.errs[i] = err
+			if !f.bestEffort {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (f *fanout) writeConcurrent(p []byte) (n int, err error) {
+	ns := make([]int, len(f.ws))
+	errs := make([]error, len(f.ws))
+	sem := make(chan struct{}, f.parallel)
+	var wg sync.WaitGroup
+	for i, w := range f.ws {
+		if f.errs[i] != nil && f.bestEffort {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w io.Writer) {
+			defer wg.Done()
+			ns[i], errs[i] = writeFull(w, p)
+			<-sem
+		}(i, w)
+	}
+	wg.Wait()
+	for i, nn := range ns {
+		f.ns[i] += int64(nn)
+		if nn > n {
+			n = nn
+		}
+		if errs[i] != nil && f.errs[i] == nil {
+			f.errs[i] = errs[i]
+		}
+	}
+	return n, joinErrors(errs...)
+}
+
+// failed reports whether every writer has failed.
+func (f *fanout) failed() bool {
+	for _, err := range f.errs {
+		if err == nil {
+			return false
+		}
+	}
+	return len(f.errs) > 0
+}
+
+// Flush flushes each writer that supports flushing.
+func (f *fanout) Flush() error {
+	errs := make([]error, len(f.ws))
+	for i, w := range f.ws {
+		switch fl := w.(type) {
+		case interface{ Flush() error }:
+			errs[i] = fl.Flush()
+		case interface{ Flush() }:
+			fl.Flush()
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// Close closes each writer that implements io.Closer.
+func (f *fanout) Close() error {
+	errs := make([]error, len(f.ws))
+	for i, w := range f.ws {
+		if c, ok := w.(io.Closer); ok {
+			errs[i] = c.Close()
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// writeFull writes p to w, reporting io.ErrShortWrite if w accepts less than
+// all of p without an error.
+func writeFull(w io.Writer, p []byte) (int, error) {
+	n, err := w.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
@@ -0,0 +1,154 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter delays each write to a buffer.
+type slowWriter struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	delay time.Duration
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAggregatedMultiWriter(t *testing.T) {
+	a, b := &bytes.Buffer{}, &bytes.Buffer{}
+	w := NewAggregatedMultiWriter([]io.Writer{a, b})
+	stringify(w, testInput)
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertString(t, testOutput, a.String())
+	assertString(t, testOutput, b.String())
+}
+
+func TestAggregatedMultiWriterFailFast(t *testing.T) {
+	expect := errors.New("broken")
+	b := &bytes.Buffer{}
+	w := NewAggregatedMultiWriter([]io.Writer{&errWriter{err: expect}, b})
+	stringify(w, testInput)
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+	assertString(t, "", b.String())
+}
+
+func TestConcurrentFanout(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	sinks := make([]*slowWriter, 4)
+	ws := make([]io.Writer, len(sinks))
+	for i := range sinks {
+		sinks[i] = &slowWriter{delay: delay}
+		ws[i] = sinks[i]
+	}
+	w := NewAggregatedMultiWriter(ws, WithConcurrentFanout(len(ws)))
+
+	start := time.Now()
+	stringify(w, testInput)
+	elapsed := time.Since(start)
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	for _, sink := range sinks {
+		assertString(t, testOutput, sink.buf.String())
+	}
+	writes := time.Duration(2*len(testInput) + 1)
+	if sequential := writes * delay * time.Duration(len(sinks)); elapsed >= sequential {
+		t.Errorf("expected less than %v, got: %v", sequential, elapsed)
+	}
+}
+
+func TestConcurrentFanoutJoinsErrors(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	b := &bytes.Buffer{}
+	w := NewAggregatedMultiWriter([]io.Writer{&errWriter{err: errA}, b, &errWriter{err: errB}},
+		WithConcurrentFanout(2))
+	n, err := w.Write([]byte(testOutput))
+	assertInt64(t, testOutputLength, int64(n))
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("expected joined errors, got: %v", err)
+	}
+	assertString(t, testOutput, b.String())
+}
+
+func TestSinkN(t *testing.T) {
+	for _, opts := range [][]MultiOption{nil, {WithConcurrentFanout(3)}} {
+		failing := &errWriter{n: 5, err: errors.New("broken")}
+		ws := []io.Writer{&bytes.Buffer{}, failing, &bytes.Buffer{}}
+		w := NewAggregatedMultiWriter(ws, opts...)
+		w.Write([]byte(testOutput))
+		w.Write([]byte(testOutput))
+
+		assertInt64(t, testOutputLength, w.N())
+		ns := w.SinkN()
+		assertInt64(t, int64(len(w.Writers())), int64(len(ns)))
+		assertInt64(t, testOutputLength, ns[0])
+		assertInt64(t, 5, ns[1])
+		if opts == nil {
+			assertInt64(t, 0, ns[2])
+		} else {
+			assertInt64(t, testOutputLength, ns[2])
+		}
+	}
+}
+
+func TestBestEffort(t *testing.T) {
+	for _, opts := range [][]MultiOption{
+		{WithBestEffort()},
+		{WithBestEffort(), WithConcurrentFanout(3)},
+	} {
+		expect := errors.New("broken")
+		a, c := &bytes.Buffer{}, &bytes.Buffer{}
+		w := NewAggregatedMultiWriter([]io.Writer{a, &errWriter{n: 5, err: expect}, c}, opts...)
+		stringify(w, testInput)
+		fatalOn(t, w.AggregatedWriter.Err())
+		assertString(t, testOutput, a.String())
+		assertString(t, testOutput, c.String())
+
+		rs := w.Results()
+		for i, expect := range []WriterResult{
+			{testOutputLength, nil},
+			{5, expect},
+			{testOutputLength, nil},
+		} {
+			if rs[i] != expect {
+				t.Errorf("expected %+v, got: %+v", expect, rs[i])
+			}
+		}
+		n, err := w.Result()
+		assertInt64(t, testOutputLength, n)
+		if !errors.Is(err, expect) {
+			t.Errorf("expected %v, got: %v", expect, err)
+		}
+	}
+}
+
+func TestBestEffortAllFailed(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	w := NewAggregatedMultiWriter([]io.Writer{
+		&errWriter{n: 1, err: errA},
+		&errWriter{n: 6, err: errB},
+	}, WithBestEffort())
+	stringify(w, testInput)
+	n, err := w.Result()
+	assertInt64(t, 6, n)
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("expected both errors, got: %v", err)
+	}
+	if _, err := w.Write([]byte("foo")); err == nil {
+		t.Errorf("expected writes to fail once every writer has failed")
+	}
+}
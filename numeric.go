@@ -0,0 +1,19 @@
+package demo
+
+import "strconv"
+
+// WriteInt writes the decimal representation of i, without the allocations
+// of fmt.
+func (w *AggregatedWriter) WriteInt(i int64) (int, error) {
+	w.lock()
+	defer w.unlock()
+	return w.writeLocked(strconv.AppendInt(w.numBuf[:0], i, 10))
+}
+
+// WriteFloat writes f formatted as by strconv.FormatFloat with the given
+// format and precision, without the allocations of fmt.
+func (w *AggregatedWriter) WriteFloat(f float64, fmt byte, prec int) (int, error) {
+	w.lock()
+	defer w.unlock()
+	return w.writeLocked(strconv.AppendFloat(w.numBuf[:0], f, fmt, prec, 64))
+}
@@ -0,0 +1,80 @@
+package demo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestWriteInt(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b)
+	for _, i := range []int64{0, 42, -7, math.MaxInt64, math.MinInt64} {
+		w.WriteInt(i)
+		w.WriteByte(',')
+	}
+	fatalOn(t, w.Err())
+	expect := "0,42,-7,9223372036854775807,-9223372036854775808,"
+	assertString(t, expect, b.String())
+	assertInt64(t, int64(len(expect)), w.N())
+}
+
+func TestWriteFloat(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b)
+	w.WriteFloat(3.14159, 'f', 2)
+	w.WriteByte(',')
+	w.WriteFloat(1e21, 'g', -1)
+	w.WriteByte(',')
+	w.WriteFloat(-0.5, 'e', 3)
+	w.WriteByte(',')
+	w.WriteFloat(1e100, 'f', 0)
+	fatalOn(t, w.Err())
+	expect := "3.14,1e+21,-5.000e-01," + fmt.Sprintf("%.0f", 1e100)
+	assertString(t, expect, b.String())
+	assertInt64(t, int64(len(expect)), w.N())
+}
+
+func TestWriteNumbersDoNotAllocate(t *testing.T) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	if allocs := testing.AllocsPerRun(100, func() {
+		w.WriteInt(-1234567890)
+		w.WriteFloat(math.Pi, 'g', -1)
+	}); allocs != 0 {
+		t.Errorf("expected no allocations, got: %v", allocs)
+	}
+}
+
+func BenchmarkWriteInt(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.WriteInt(int64(i))
+	}
+}
+
+func BenchmarkWriteIntFprintf(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fmt.Fprintf(w, "%d", int64(i))
+	}
+}
+
+func BenchmarkWriteFloat(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.WriteFloat(float64(i)/3, 'g', -1)
+	}
+}
+
+func BenchmarkWriteFloatFprintf(b *testing.B) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fmt.Fprintf(w, "%g", float64(i)/3)
+	}
+}
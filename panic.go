@@ -0,0 +1,26 @@
+package demo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWriterPanic is wrapped by the error returned when the underlying writer
+// panics and panic recovery is enabled.
+var ErrWriterPanic = errors.New("demo: underlying writer panicked")
+
+// WithPanicRecovery configures w to recover from panics in the underlying
+// writer and treat them as a failed write. This masks what is usually a
+// programming error in the underlying writer, so use it sparingly.
+func WithPanicRecovery() Option {
+	return func(w *AggregatedWriter) { w.recoverPanics = true }
+}
+
+func (w *AggregatedWriter) safeWrite(p []byte) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = 0, fmt.Errorf("%w: %v", ErrWriterPanic, r)
+		}
+	}()
+	return w.w.Write(p)
+}
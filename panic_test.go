@@ -0,0 +1,27 @@
+package demo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) { panic("template exploded") }
+
+func TestPanicRecovery(t *testing.T) {
+	w := NewAggregatedWriter(panicWriter{}, WithPanicRecovery())
+	n, err := fmt.Fprint(w, "foo")
+	assertInt64(t, 0, int64(n))
+	if !errors.Is(err, ErrWriterPanic) {
+		t.Fatalf("expected %v, got: %v", ErrWriterPanic, err)
+	}
+	if !strings.Contains(err.Error(), "template exploded") {
+		t.Errorf("expected recovered value in error, got: %v", err)
+	}
+	if w.Err() != err {
+		t.Errorf("expected %v, got: %v", err, w.Err())
+	}
+}
@@ -0,0 +1,120 @@
+package demo
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrBadReadCount is returned when the underlying reader reports reading
+// fewer than zero or more than len(p) bytes.
+var ErrBadReadCount = errors.New("demo: invalid count from underlying reader")
+
+// AggregatedReader is the read-side counterpart of AggregatedWriter. It
+// counts the bytes read from an underlying reader and stores the first
+// error, after which every read returns that error without reading.
+// Timeouts, such as a net.Error after a read deadline expires, are returned
+// but not stored, so that reading may resume once the deadline is extended.
+type AggregatedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+// NewAggregatedReader returns an AggregatedReader that reads from r. If r is
+// already an *AggregatedReader, r itself is returned.
+func NewAggregatedReader(r io.Reader) *AggregatedReader {
+	if ar, ok := r.(*AggregatedReader); ok {
+		return ar
+	}
+	return &AggregatedReader{r: r}
+}
+
+// Read implements io.Reader.
+func (r *AggregatedReader) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err = r.r.Read(p)
+	if n < 0 || n > len(p) {
+		n = clampN(n, 0, len(p))
+		if err == nil {
+			err = ErrBadReadCount
+		}
+	}
+	r.n += int64(n)
+	r.latch(err)
+	return
+}
+
+// ReadByte implements io.ByteReader, using the underlying reader's ReadByte
+// if it has one.
+func (r *AggregatedReader) ReadByte() (byte, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	br, ok := r.r.(io.ByteReader)
+	if !ok {
+		var b [1]byte
+		_, err := io.ReadFull(r, b[:])
+		return b[0], err
+	}
+	c, err := br.ReadByte()
+	if err != nil {
+		r.latch(err)
+		return 0, err
+	}
+	r.n++
+	return c, nil
+}
+
+// ReadRune implements io.RuneReader, using the underlying reader's ReadRune
+// if it has one. Otherwise it reads one byte at a time, so that no bytes
+// beyond the rune are consumed, and size reports every byte consumed even
+// if they do not form a valid rune.
+func (r *AggregatedReader) ReadRune() (ch rune, size int, err error) {
+	if r.err != nil {
+		return 0, 0, r.err
+	}
+	if rr, ok := r.r.(io.RuneReader); ok {
+		ch, size, err = rr.ReadRune()
+		r.n += int64(size)
+		r.latch(err)
+		return
+	}
+	var buf [utf8.UTFMax]byte
+	for size < len(buf) && !utf8.FullRune(buf[:size]) {
+		if buf[size], err = r.ReadByte(); err != nil {
+			if size > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+				r.latch(err)
+			}
+			return 0, size, err
+		}
+		size++
+	}
+	ch, _ = utf8.DecodeRune(buf[:size])
+	return
+}
+
+// latch stores err unless it is a timeout.
+func (r *AggregatedReader) latch(err error) {
+	var t interface{ Timeout() bool }
+	if errors.As(err, &t) && t.Timeout() {
+		return
+	}
+	r.err = err
+}
+
+func (r *AggregatedReader) N() int64 { return r.n }
+
+// Err returns the first error returned by the underlying reader, or nil if
+// it was io.EOF.
+func (r *AggregatedReader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+func (r *AggregatedReader) Result() (n int64, err error) { return r.n, r.Err() }
@@ -0,0 +1,98 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// errReader fails every read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestAggregatedReader(t *testing.T) {
+	r := NewAggregatedReader(strings.NewReader(testOutput))
+	if NewAggregatedReader(r) != r {
+		t.Errorf("expected nested reader to be reused")
+	}
+	b, err := ioutil.ReadAll(r)
+	fatalOn(t, err)
+	assertString(t, testOutput, string(b))
+	n, err := r.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected %v, got: %v", io.EOF, err)
+	}
+}
+
+func TestAggregatedReaderError(t *testing.T) {
+	expect := errors.New("broken")
+	r := NewAggregatedReader(io.MultiReader(strings.NewReader("foo"), errReader{expect}))
+	var p [8]byte
+	for i := 0; i < 3; i++ {
+		r.Read(p[:])
+	}
+	n, err := r.Result()
+	assertInt64(t, 3, n)
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	if _, err := r.ReadByte(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+}
+
+func TestAggregatedReaderReadByte(t *testing.T) {
+	for _, ur := range []io.Reader{
+		strings.NewReader("foo"),
+		iotest.OneByteReader(strings.NewReader("foo")),
+	} {
+		r := NewAggregatedReader(ur)
+		var b bytes.Buffer
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				break
+			}
+			b.WriteByte(c)
+		}
+		assertString(t, "foo", b.String())
+		n, err := r.Result()
+		fatalOn(t, err)
+		assertInt64(t, 3, n)
+	}
+}
+
+func TestAggregatedReaderReadRune(t *testing.T) {
+	const s = "héllo, 世界"
+	for _, ur := range []io.Reader{
+		strings.NewReader(s),
+		iotest.OneByteReader(strings.NewReader(s)),
+	} {
+		r := NewAggregatedReader(ur)
+		var b strings.Builder
+		for {
+			ch, _, err := r.ReadRune()
+			if err != nil {
+				break
+			}
+			b.WriteRune(ch)
+		}
+		assertString(t, s, b.String())
+		n, err := r.Result()
+		fatalOn(t, err)
+		assertInt64(t, int64(len(s)), n)
+	}
+
+	r := NewAggregatedReader(iotest.OneByteReader(strings.NewReader("\xe4\xb8")))
+	if _, _, err := r.ReadRune(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v, got: %v", io.ErrUnexpectedEOF, err)
+	}
+	assertInt64(t, 2, r.N())
+}
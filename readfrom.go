@@ -0,0 +1,120 @@
+package demo
+
+import (
+	"io"
+	"sync"
+)
+
+// Copy buffers used by ReadFrom start at minCopyBuffer bytes and double each
+// time a read fills them, up to maxCopyBuffer bytes.
+const (
+	minCopyBuffer = 512
+	maxCopyBuffer = 1 << 20
+	copyClasses   = 12 // minCopyBuffer << (copyClasses - 1) == maxCopyBuffer
+)
+
+// copyBuffers pools copy buffers by size class.
+var copyBuffers [copyClasses]sync.Pool
+
+func getCopyBuffer(class int) *[]byte {
+	if b, ok := copyBuffers[class].Get().(*[]byte); ok {
+		return b
+	}
+	b := make([]byte, minCopyBuffer<<class)
+	return &b
+}
+
+func putCopyBuffer(class int, b *[]byte) { copyBuffers[class].Put(b) }
+
+// WithBuffer configures ReadFrom to copy through buf rather than its own
+// pooled buffers. An empty buf is ignored. Buffers smaller than 512 bytes
+// work but make many small writes to the underlying writer.
+func WithBuffer(buf []byte) Option {
+	return func(w *AggregatedWriter) { w.copyBuf = buf }
+}
+
+// ReadFrom implements io.ReaderFrom. It reads from r until EOF or an error,
+// writing everything read to w. Unless configured with WithBuffer, the copy
+// buffer starts small and grows as long as reads keep filling it, so small
+// streams stay cheap while large streams need fewer calls to the underlying
+// writer.
+//
+// If w has no options configured and the underlying writer implements
+// io.ReaderFrom, as *os.File and *net.TCPConn do, ReadFrom delegates to it so
+// that fast paths such as sendfile still apply. The underlying writer cannot
+// tell read errors from write errors, so in that case any error is stored.
+func (w *AggregatedWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	if rf, ok := w.passthrough().(io.ReaderFrom); ok {
+		w.lock()
+		defer w.unlock()
+		if err = w.check(); err != nil {
+			return 0, err
+		}
+		n, err = rf.ReadFrom(r)
+		if n < 0 {
+			n = 0
+		}
+		w.record(n, err)
+		return n, err
+	}
+	var pooled *[]byte
+	class := 0
+	buf := w.copyBuf
+	if len(buf) == 0 {
+		pooled = getCopyBuffer(class)
+		buf = *pooled
+		defer func() { putCopyBuffer(class, pooled) }()
+	}
+	for {
+		nr, rerr := r.Read(buf)
+		if nr > 0 {
+			nw, werr := w.Write(buf[:nr])
+			n += int64(nw)
+			if werr != nil {
+				return n, werr
+			}
+			if nw != nr {
+				return n, io.ErrShortWrite
+			}
+			if pooled != nil && nr == len(buf) && class < copyClasses-1 {
+				putCopyBuffer(class, pooled)
+				class++
+				pooled = getCopyBuffer(class)
+				buf = *pooled
+			}
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
+
+// passthrough returns the underlying writer if no options are configured,
+// so that its fast paths can be used without bypassing any feature of w.
+func (w *AggregatedWriter) passthrough() io.Writer {
+	if !w.plain || w.factory != nil {
+		return nil
+	}
+	return w.w
+}
+
+// record accounts for n bytes written to the underlying writer by a fast
+// path, and the error, if any, with which it failed.
+func (w *AggregatedWriter) record(n int64, err error) {
+	w.touched = true
+	w.n += n
+	w.unflushed += n
+	w.view.addBytes(n)
+	if err != nil {
+		w.errors++
+		w.view.addError()
+		w.fail(err)
+		return
+	}
+	w.writes++
+	w.view.addWrite()
+	w.observeWrite(n)
+}
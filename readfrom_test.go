@@ -0,0 +1,178 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// countingReader reads from r, counting calls to Read.
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads++
+	return c.r.Read(p)
+}
+
+// countingWriter discards everything written to it, counting calls to Write.
+type countingWriter struct {
+	writes int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.writes++
+	return len(p), nil
+}
+
+func TestReadFrom(t *testing.T) {
+	input := strings.Repeat(testOutput, 4096)
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b)
+	n, err := w.ReadFrom(&countingReader{r: strings.NewReader(input)})
+	fatalOn(t, err)
+	assertInt64(t, int64(len(input)), n)
+	assertInt64(t, int64(len(input)), w.N())
+	assertString(t, input, b.String())
+}
+
+func TestReadFromWriteError(t *testing.T) {
+	expect := errors.New("broken")
+	w := NewAggregatedWriter(&errWriter{n: 3, err: expect})
+	n, err := w.ReadFrom(strings.NewReader(testOutput))
+	assertInt64(t, 3, n)
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+}
+
+var copySizes = []struct {
+	name string
+	size int
+}{
+	{"Small", 1 << 10},
+	{"Medium", 256 << 10},
+	{"Large", 16 << 20},
+}
+
+func BenchmarkReadFrom(b *testing.B) {
+	for _, tt := range copySizes {
+		src := make([]byte, tt.size)
+		b.Run(tt.name, func(b *testing.B) {
+			b.SetBytes(int64(tt.size))
+			b.ReportAllocs()
+			var calls int
+			for i := 0; i < b.N; i++ {
+				cr := &countingReader{r: bytes.NewReader(src)}
+				cw := &countingWriter{}
+				NewAggregatedWriter(cw).ReadFrom(cr)
+				calls += cr.reads + cw.writes
+			}
+			b.ReportMetric(float64(calls)/float64(b.N), "calls/op")
+		})
+	}
+}
+
+func BenchmarkReadFromFixedBuffer(b *testing.B) {
+	for _, tt := range copySizes {
+		src := make([]byte, tt.size)
+		b.Run(tt.name, func(b *testing.B) {
+			b.SetBytes(int64(tt.size))
+			b.ReportAllocs()
+			var calls int
+			for i := 0; i < b.N; i++ {
+				cr := &countingReader{r: bytes.NewReader(src)}
+				cw := &countingWriter{}
+				io.CopyBuffer(cw, cr, make([]byte, 32<<10))
+				calls += cr.reads + cw.writes
+			}
+			b.ReportMetric(float64(calls)/float64(b.N), "calls/op")
+		})
+	}
+}
+
+func TestReadFromWithBuffer(t *testing.T) {
+	input := strings.Repeat(testOutput, 64)
+	for _, buf := range [][]byte{nil, {}, make([]byte, 1), make([]byte, 100)} {
+		b := &bytes.Buffer{}
+		cw := &countingWriter{}
+		w := NewAggregatedWriter(io.MultiWriter(b, cw), WithBuffer(buf))
+		n, err := w.ReadFrom(strings.NewReader(input))
+		fatalOn(t, err)
+		assertInt64(t, int64(len(input)), n)
+		assertString(t, input, b.String())
+		if len(buf) > 0 {
+			assertInt64(t, int64((len(input)+len(buf)-1)/len(buf)), int64(cw.writes))
+		}
+	}
+}
+
+// fastPathWriter counts calls to its io.ReaderFrom and io.StringWriter fast
+// paths.
+type fastPathWriter struct {
+	bytes.Buffer
+	readFroms, writeStrings int
+}
+
+func (w *fastPathWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFroms++
+	return w.Buffer.ReadFrom(r)
+}
+
+func (w *fastPathWriter) WriteString(s string) (int, error) {
+	w.writeStrings++
+	return w.Buffer.WriteString(s)
+}
+
+func TestFastPaths(t *testing.T) {
+	b := &fastPathWriter{}
+	w := NewAggregatedWriter(b)
+	n, err := w.ReadFrom(strings.NewReader(testOutput))
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	_, err = w.WriteString("foo")
+	fatalOn(t, err)
+
+	assertInt64(t, 1, int64(b.readFroms))
+	assertInt64(t, 1, int64(b.writeStrings))
+	assertString(t, testOutput+"foo", b.String())
+	assertInt64(t, testOutputLength+3, w.N())
+	assertInt64(t, 2, w.WriteCount())
+	assertInt64(t, testOutputLength+3, w.StatsView().N())
+
+	fatalOn(t, w.Close())
+	if _, err := w.WriteString("foo"); err != io.ErrClosedPipe {
+		t.Errorf("expected %v, got: %v", io.ErrClosedPipe, err)
+	}
+	assertInt64(t, 1, int64(b.writeStrings))
+}
+
+func TestFastPathsDisabledByOptions(t *testing.T) {
+	b := &fastPathWriter{}
+	w := NewAggregatedWriter(b, WithLineCallback(func([]byte) {}))
+	w.ReadFrom(strings.NewReader(testOutput))
+	w.WriteString("foo")
+	assertInt64(t, 0, int64(b.readFroms))
+	assertInt64(t, 0, int64(b.writeStrings))
+	assertInt64(t, testOutputLength+3, w.N())
+}
+
+func TestFastPathReadFromError(t *testing.T) {
+	expect := errors.New("broken")
+	w := NewAggregatedWriter(&fastPathWriter{})
+	n, err := w.ReadFrom(io.MultiReader(strings.NewReader("foo"), errReader{expect}))
+	assertInt64(t, 3, n)
+	if err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+}
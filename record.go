@@ -0,0 +1,90 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnexpectedN is returned by Close if N differs from the total
+	// configured with WithExpectedFinalN.
+	ErrUnexpectedN = errors.New("demo: unexpected final length")
+
+	// ErrMisaligned is returned by Close if N is not a multiple of the size
+	// configured with WithRequireRecordAligned.
+	ErrMisaligned = errors.New("demo: stream not record aligned")
+)
+
+// WithRecordSize configures the record size used by RecordOffset,
+// RecordRemaining and PadToRecord, for fixed-length record formats.
+func WithRecordSize(size int64) Option {
+	return func(w *AggregatedWriter) { w.recordSize = size }
+}
+
+// RecordOffset returns the offset of N within the current record.
+func (w *AggregatedWriter) RecordOffset() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.recordOffset()
+}
+
+// RecordRemaining returns the number of bytes needed to complete the current
+// record, or zero if N falls on a record boundary.
+func (w *AggregatedWriter) RecordRemaining() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.recordRemaining()
+}
+
+// PadToRecord writes fill bytes up to the next record boundary.
+func (w *AggregatedWriter) PadToRecord(fill byte) (int, error) {
+	w.lock()
+	defer w.unlock()
+	remaining := w.recordRemaining()
+	if remaining == 0 {
+		return 0, w.check()
+	}
+	return w.writeLocked(bytes.Repeat([]byte{fill}, int(remaining)))
+}
+
+// recordOffset is RecordOffset for callers that hold the lock.
+func (w *AggregatedWriter) recordOffset() int64 {
+	if w.recordSize <= 0 {
+		return 0
+	}
+	return w.n % w.recordSize
+}
+
+// recordRemaining is RecordRemaining for callers that hold the lock.
+func (w *AggregatedWriter) recordRemaining() int64 {
+	if w.recordSize <= 0 {
+		return 0
+	}
+	return (w.recordSize - w.recordOffset()) % w.recordSize
+}
+
+// WithExpectedFinalN configures Close to fail with ErrUnexpectedN if fewer
+// or more than n bytes were written, catching truncated or over-long
+// streams.
+func WithExpectedFinalN(n int64) Option {
+	return func(w *AggregatedWriter) { w.expectN, w.checkN = n, true }
+}
+
+// WithRequireRecordAligned configures Close to fail with ErrMisaligned if N
+// is not a multiple of size.
+func WithRequireRecordAligned(size int64) Option {
+	return func(w *AggregatedWriter) { w.alignTo = size }
+}
+
+// checkFinal returns an error if N does not meet the expectations checked
+// by Close.
+func (w *AggregatedWriter) checkFinal() error {
+	if w.checkN && w.n != w.expectN {
+		return fmt.Errorf("%w: wrote %d bytes, expected %d", ErrUnexpectedN, w.n, w.expectN)
+	}
+	if w.alignTo > 0 && w.n%w.alignTo != 0 {
+		return fmt.Errorf("%w: wrote %d bytes, %d past a %d byte boundary", ErrMisaligned, w.n, w.n%w.alignTo, w.alignTo)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRecordSize(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithRecordSize(8))
+	assertInt64(t, 0, w.RecordOffset())
+	assertInt64(t, 0, w.RecordRemaining())
+
+	w.Write([]byte("foo"))
+	assertInt64(t, 3, w.RecordOffset())
+	assertInt64(t, 5, w.RecordRemaining())
+
+	w.Write([]byte("barbazqux"))
+	assertInt64(t, 4, w.RecordOffset())
+	assertInt64(t, 4, w.RecordRemaining())
+
+	n, err := w.PadToRecord(' ')
+	fatalOn(t, err)
+	assertInt64(t, 4, int64(n))
+	assertInt64(t, 16, w.N())
+	assertInt64(t, 0, w.RecordOffset())
+	assertString(t, "foobarbazqux    ", b.String())
+
+	n, err = w.PadToRecord(' ')
+	fatalOn(t, err)
+	assertInt64(t, 0, int64(n))
+	assertInt64(t, 16, w.N())
+}
+
+func TestExpectedFinalN(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithExpectedFinalN(testOutputLength))
+	stringify(w, testInput)
+	fatalOn(t, w.Close())
+
+	b := &closeCounter{}
+	w = NewAggregatedWriter(b, WithExpectedFinalN(testOutputLength))
+	stringify(w, testInput[:2])
+	if err := w.Close(); !errors.Is(err, ErrUnexpectedN) {
+		t.Fatalf("expected %v, got: %v", ErrUnexpectedN, err)
+	}
+	if !errors.Is(w.Err(), ErrUnexpectedN) {
+		t.Errorf("expected %v, got: %v", ErrUnexpectedN, w.Err())
+	}
+	assertInt64(t, 1, int64(b.closed))
+}
+
+func TestRequireRecordAligned(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithRequireRecordAligned(7))
+	stringify(w, testInput)
+	fatalOn(t, w.Close())
+
+	b := &closeCounter{}
+	w = NewAggregatedWriter(b, WithRequireRecordAligned(8))
+	stringify(w, testInput)
+	err := w.Close()
+	if !errors.Is(err, ErrMisaligned) {
+		t.Fatalf("expected %v, got: %v", ErrMisaligned, err)
+	}
+	assertString(t, "demo: stream not record aligned: wrote 21 bytes, 5 past a 8 byte boundary", err.Error())
+	if !errors.Is(w.Err(), ErrMisaligned) {
+		t.Errorf("expected %v, got: %v", ErrMisaligned, w.Err())
+	}
+	assertInt64(t, 1, int64(b.closed))
+}
@@ -0,0 +1,56 @@
+package demo
+
+import "io"
+
+// ShortWritePolicy determines what an AggregatedWriter does when the
+// underlying writer accepts fewer bytes than it was given without
+// returning an error.
+type ShortWritePolicy int
+
+const (
+	// ShortWriteIgnore returns the short count without an error. This is the
+	// default.
+	ShortWriteIgnore ShortWritePolicy = iota
+
+	// ShortWriteFail fails the write with io.ErrShortWrite.
+	ShortWriteFail
+
+	// ShortWriteRetry writes the remaining bytes again until all are
+	// written or the underlying writer fails. A write that makes no progress
+	// fails with io.ErrShortWrite.
+	ShortWriteRetry
+)
+
+// WithShortWritePolicy configures how w handles short writes by the
+// underlying writer.
+func WithShortWritePolicy(policy ShortWritePolicy) Option {
+	return func(w *AggregatedWriter) { w.shortWrites = policy }
+}
+
+// writeUnderlying writes p to the underlying writer, applying the short
+// write policy.
+func (w *AggregatedWriter) writeUnderlying(p []byte) (n int, err error) {
+	n, err = w.writeOnce(p)
+	for w.shortWrites == ShortWriteRetry && err == nil && n > 0 && n < len(p) {
+		var nn int
+		nn, err = w.writeOnce(p[n:])
+		if nn < 0 || nn > len(p)-n {
+			return n + clampN(nn, 0, len(p)-n), ErrBadWriteCount
+		}
+		if nn == 0 && err == nil {
+			err = io.ErrShortWrite
+		}
+		n += nn
+	}
+	if w.shortWrites != ShortWriteIgnore && err == nil && n >= 0 && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+func (w *AggregatedWriter) writeOnce(p []byte) (int, error) {
+	if w.recoverPanics {
+		return w.safeWrite(p)
+	}
+	return w.w.Write(p)
+}
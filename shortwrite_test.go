@@ -0,0 +1,52 @@
+package demo
+
+import (
+	"io"
+	"testing"
+)
+
+func TestShortWritePolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy ShortWritePolicy
+		n      int64
+		err    error
+	}{
+		{ShortWriteIgnore, 4, nil},
+		{ShortWriteFail, 4, io.ErrShortWrite},
+		{ShortWriteRetry, testOutputLength, nil},
+	} {
+		b := &shortWriter{short: 1, max: 4}
+		w := NewAggregatedWriter(b, WithShortWritePolicy(tt.policy))
+		n, err := w.Write([]byte(testOutput))
+		assertInt64(t, tt.n, int64(n))
+		if err != tt.err {
+			t.Errorf("expected %v, got: %v", tt.err, err)
+		}
+		assertInt64(t, tt.n, w.N())
+		assertString(t, testOutput[:tt.n], b.String())
+	}
+}
+
+func TestShortWriteRetryNoProgress(t *testing.T) {
+	z := &zeroAfterWriter{n: 2}
+	w := NewAggregatedWriter(z, WithShortWritePolicy(ShortWriteRetry))
+	n, err := w.Write([]byte("foobar"))
+	assertInt64(t, 2, int64(n))
+	if err != io.ErrShortWrite {
+		t.Errorf("expected %v, got: %v", io.ErrShortWrite, err)
+	}
+	assertInt64(t, 2, int64(z.calls))
+	assertInt64(t, 2, w.N())
+}
+
+// zeroAfterWriter accepts n bytes and then nothing, without an error.
+type zeroAfterWriter struct {
+	n, calls int
+}
+
+func (w *zeroAfterWriter) Write(p []byte) (int, error) {
+	w.calls++
+	n := clampN(w.n, 0, len(p))
+	w.n -= n
+	return n, nil
+}
@@ -0,0 +1,197 @@
+package demo
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the activity of an AggregatedWriter. It implements
+// expvar.Var, so it can be published with expvar.Func.
+type Stats struct {
+	Bytes  int64 // bytes written to the underlying writer
+	Writes int64 // successful calls to Write
+	Errors int64 // failed calls to Write
+	Err    error // the sticky error, if any
+
+	MinWrite   int64     // bytes written by the smallest successful Write
+	MaxWrite   int64     // bytes written by the largest successful Write
+	FirstWrite time.Time // time of the first successful Write
+	LastWrite  time.Time // time of the last successful Write
+	Throughput float64   // bytes per second between FirstWrite and LastWrite
+}
+
+// String returns s as JSON, as expvar.Var requires.
+func (s Stats) String() string {
+	b, _ := s.MarshalJSON()
+	return string(b)
+}
+
+// MarshalJSON implements json.Marshaler. Times are omitted if there have
+// been no writes, and Err is given as a string.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Bytes      int64      `json:"bytes"`
+		Writes     int64      `json:"writes"`
+		Errors     int64      `json:"errors"`
+		MinWrite   int64      `json:"min_write"`
+		MaxWrite   int64      `json:"max_write"`
+		FirstWrite *time.Time `json:"first_write,omitempty"`
+		LastWrite  *time.Time `json:"last_write,omitempty"`
+		Throughput float64    `json:"throughput"`
+		Err        string     `json:"error,omitempty"`
+	}{
+		Bytes:      s.Bytes,
+		Writes:     s.Writes,
+		Errors:     s.Errors,
+		MinWrite:   s.MinWrite,
+		MaxWrite:   s.MaxWrite,
+		Throughput: s.Throughput,
+	}
+	if !s.FirstWrite.IsZero() {
+		v.FirstWrite, v.LastWrite = &s.FirstWrite, &s.LastWrite
+	}
+	if s.Err != nil {
+		v.Err = s.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// Stats returns a snapshot of the activity of w.
+func (w *AggregatedWriter) Stats() Stats {
+	w.lock()
+	defer w.unlock()
+	return w.stats()
+}
+
+func (w *AggregatedWriter) stats() Stats {
+	var rate float64
+	if d := w.lastWrite.Sub(w.firstWrite); d > 0 {
+		rate = float64(w.n) / d.Seconds()
+	}
+	return Stats{
+		Bytes:      w.n,
+		Writes:     w.writes,
+		Errors:     w.errors,
+		Err:        w.loadErr(),
+		MinWrite:   w.minWrite,
+		MaxWrite:   w.maxWrite,
+		FirstWrite: w.firstWrite,
+		LastWrite:  w.lastWrite,
+		Throughput: rate,
+	}
+}
+
+// observeWrite records the size and time of a successful write of n bytes.
+func (w *AggregatedWriter) observeWrite(n int64) {
+	now := w.now()
+	if w.writes == 1 {
+		w.minWrite, w.maxWrite, w.firstWrite = n, n, now
+	} else if n < w.minWrite {
+		w.minWrite = n
+	} else if n > w.maxWrite {
+		w.maxWrite = n
+	}
+	w.lastWrite = now
+}
+
+// WriteCount returns the number of calls to Write that succeeded.
+func (w *AggregatedWriter) WriteCount() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.writes
+}
+
+// AvgWriteSize returns the mean number of bytes written per successful call
+// to Write, or zero if no writes have succeeded.
+func (w *AggregatedWriter) AvgWriteSize() float64 {
+	w.lock()
+	defer w.unlock()
+	if w.writes == 0 {
+		return 0
+	}
+	return float64(w.n) / float64(w.writes)
+}
+
+// SinceFlush returns the number of bytes written since the last successful
+// call to Flush or Sync, whether or not the underlying writer is buffered.
+func (w *AggregatedWriter) SinceFlush() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.unflushed
+}
+
+// WithBlockSize configures the block size used by BilledN.
+func WithBlockSize(block int64) Option {
+	return func(w *AggregatedWriter) { w.blockSize = block }
+}
+
+// BilledN returns N rounded up to a multiple of the block size configured
+// with WithBlockSize, or N if no block size was given.
+func (w *AggregatedWriter) BilledN() int64 {
+	w.lock()
+	defer w.unlock()
+	if w.blockSize <= 0 {
+		return w.n
+	}
+	return (w.n + w.blockSize - 1) / w.blockSize * w.blockSize
+}
+
+// StatsView holds copies of the counters of an AggregatedWriter that may be
+// read from any goroutine, without synchronizing with writers.
+type StatsView struct {
+	bytes, writes, errors    int64
+	ibytes, iwrites, ierrors int64 // since the last ReadAndReset
+}
+
+// StatsView returns a view of the counters of w that is safe to poll
+// concurrently with writes. The view stays current for the life of w.
+func (w *AggregatedWriter) StatsView() *StatsView { return &w.view }
+
+// N returns the number of bytes written to the underlying writer.
+func (v *StatsView) N() int64 { return atomic.LoadInt64(&v.bytes) }
+
+// Writes returns the number of calls to Write that succeeded.
+func (v *StatsView) Writes() int64 { return atomic.LoadInt64(&v.writes) }
+
+// Errors returns the number of calls to Write that failed.
+func (v *StatsView) Errors() int64 { return atomic.LoadInt64(&v.errors) }
+
+// ReadAndReset returns the bytes, writes and errors counted since the last
+// call to ReadAndReset, and resets them to zero, for reporting deltas each
+// interval. Totals reported by N and Stats are unaffected. It is safe to
+// call concurrently with writes; every write is counted by exactly one call.
+// With WithLocking, the counters are read and reset in one operation, so a
+// write's bytes and the write itself fall in the same interval; without it,
+// they may fall in consecutive intervals. Err is not set.
+func (w *AggregatedWriter) ReadAndReset() Stats {
+	w.lock()
+	defer w.unlock()
+	v := &w.view
+	return Stats{
+		Bytes:  atomic.SwapInt64(&v.ibytes, 0),
+		Writes: atomic.SwapInt64(&v.iwrites, 0),
+		Errors: atomic.SwapInt64(&v.ierrors, 0),
+	}
+}
+
+func (v *StatsView) addBytes(n int64) {
+	atomic.AddInt64(&v.bytes, n)
+	atomic.AddInt64(&v.ibytes, n)
+}
+
+func (v *StatsView) addWrite() {
+	atomic.AddInt64(&v.writes, 1)
+	atomic.AddInt64(&v.iwrites, 1)
+}
+
+func (v *StatsView) addError() {
+	atomic.AddInt64(&v.errors, 1)
+	atomic.AddInt64(&v.ierrors, 1)
+}
+
+func (v *StatsView) reset() {
+	for _, c := range []*int64{&v.bytes, &v.writes, &v.errors, &v.ibytes, &v.iwrites, &v.ierrors} {
+		atomic.StoreInt64(c, 0)
+	}
+}
@@ -0,0 +1,248 @@
+package demo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func stringify(w io.Writer, a []string) {
+	w.Write([]byte{'['})
+	for i := 0; i < len(a); i++ {
+		if i > 0 {
+			fmt.Fprint(w, ", ")
+		}
+		fmt.Fprintf(w, `"%s"`, a[i])
+	}
+	w.Write([]byte{']'})
+}
+
+func TestAvgWriteSize(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	if avg := w.AvgWriteSize(); avg != 0 {
+		t.Errorf("expected 0, got: %f", avg)
+	}
+	stringify(w, testInput)
+	writes := int64(2*len(testInput) + 1)
+	assertInt64(t, writes, w.WriteCount())
+	if avg, expect := w.AvgWriteSize(), float64(testOutputLength)/float64(writes); avg != expect {
+		t.Errorf("expected %f, got: %f", expect, avg)
+	}
+}
+
+func TestSinceFlush(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	fmt.Fprint(w, "foo")
+	assertInt64(t, 3, w.SinceFlush())
+	fatalOn(t, w.Flush())
+	assertInt64(t, 0, w.SinceFlush())
+	stringify(w, testInput)
+	assertInt64(t, testOutputLength, w.SinceFlush())
+	fatalOn(t, w.Sync())
+	assertInt64(t, 0, w.SinceFlush())
+	assertInt64(t, 3+testOutputLength, w.N())
+}
+
+func TestBilledN(t *testing.T) {
+	for _, tt := range []struct {
+		n, billed int64
+	}{
+		{0, 0},
+		{1, 4096},
+		{4096, 4096},
+		{4097, 8192},
+		{8192, 8192},
+	} {
+		w := NewAggregatedWriter(ioutil.Discard, WithBlockSize(4096))
+		w.Write(make([]byte, tt.n))
+		assertInt64(t, tt.n, w.N())
+		assertInt64(t, tt.billed, w.BilledN())
+	}
+}
+
+func TestStatsView(t *testing.T) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	v := w.StatsView()
+	const writes = 1000
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var n, count int64
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			nn, cc := v.N(), v.Writes()
+			if nn < n || cc < count {
+				t.Errorf("counters went backwards: %d/%d after %d/%d", nn, cc, n, count)
+				return
+			}
+			n, count = nn, cc
+		}
+	}()
+	for i := 0; i < writes; i++ {
+		w.Write([]byte("foo"))
+	}
+	close(done)
+	wg.Wait()
+
+	assertInt64(t, 3*writes, v.N())
+	assertInt64(t, writes, v.Writes())
+	assertInt64(t, 0, v.Errors())
+
+	w.Reset(&errWriter{err: errors.New("broken")})
+	assertInt64(t, 0, v.N())
+	w.Write([]byte("foo"))
+	assertInt64(t, 1, v.Errors())
+}
+
+func TestReadAndReset(t *testing.T) {
+	w := NewAggregatedWriter(ioutil.Discard)
+	const writers, writes = 4, 500
+
+	var mu sync.Mutex // serializes writers, but not the reader
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				mu.Lock()
+				w.Write([]byte("foo"))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var total Stats
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		case <-time.After(time.Millisecond):
+		}
+		delta := w.ReadAndReset()
+		total.Bytes += delta.Bytes
+		total.Writes += delta.Writes
+	}
+
+	assertInt64(t, 3*writers*writes, total.Bytes)
+	assertInt64(t, writers*writes, total.Writes)
+	assertInt64(t, w.N(), total.Bytes)
+	assertInt64(t, 0, w.ReadAndReset().Bytes)
+	assertInt64(t, 3*writers*writes, w.StatsView().N())
+}
+
+func TestReadAndResetLocking(t *testing.T) {
+	w := NewAggregatedWriter(ioutil.Discard, WithLocking())
+	const writers, writes = 4, 500
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				w.Write([]byte("foo"))
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		if delta := w.ReadAndReset(); delta.Bytes != 3*delta.Writes {
+			t.Fatalf("expected 3 bytes per write, got %d bytes in %d writes", delta.Bytes, delta.Writes)
+		}
+	}
+}
+
+var _ expvar.Var = Stats{}
+
+func TestStatsWriteSizes(t *testing.T) {
+	clock := newFakeClock()
+	w := NewAggregatedWriter(&bytes.Buffer{}, WithClock(clock))
+	assertString(t, `{"bytes":0,"writes":0,"errors":0,"min_write":0,"max_write":0,"throughput":0}`, w.Stats().String())
+
+	start := clock.Now()
+	for _, s := range []string{"[", `"foo"`, ", ", `"bar"`, ", ", `"baz"`, "]"} {
+		w.Write([]byte(s))
+		clock.Advance(500 * time.Millisecond)
+	}
+	s := w.Stats()
+	assertInt64(t, testOutputLength, s.Bytes)
+	assertInt64(t, 1, s.MinWrite)
+	assertInt64(t, 5, s.MaxWrite)
+	if !s.FirstWrite.Equal(start) || !s.LastWrite.Equal(start.Add(3*time.Second)) {
+		t.Errorf("expected writes from %v to %v, got: %v to %v", start, start.Add(3*time.Second), s.FirstWrite, s.LastWrite)
+	}
+	if s.Throughput != 7 {
+		t.Errorf("expected 7, got: %f", s.Throughput)
+	}
+
+	w.Reset(&errWriter{err: errors.New("broken")})
+	w.Write([]byte("foo"))
+	var v map[string]interface{}
+	fatalOn(t, json.Unmarshal([]byte(w.Stats().String()), &v))
+	if v["error"] != "broken" || v["errors"] != 1.0 || v["first_write"] != nil {
+		t.Errorf("unexpected stats: %v", v)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(Stats{
+		Bytes: 21, Writes: 7, MinWrite: 1, MaxWrite: 5,
+		FirstWrite: at, LastWrite: at.Add(3 * time.Second), Throughput: 7,
+	})
+	fatalOn(t, err)
+	assertString(t, `{"bytes":21,"writes":7,"errors":0,"min_write":1,"max_write":5,`+
+		`"first_write":"2020-01-01T00:00:00Z","last_write":"2020-01-01T00:00:03Z","throughput":7}`, string(b))
+}
+
+func TestStatsErr(t *testing.T) {
+	expect := errors.New("write failed: " + strings.Repeat("x", 1000))
+	w := NewAggregatedWriter(&errWriter{err: expect}, WithMaxErrorLength(12))
+	fmt.Fprint(w, "foo")
+	b, err := json.Marshal(w.Stats())
+	fatalOn(t, err)
+	var v map[string]interface{}
+	fatalOn(t, json.Unmarshal(b, &v))
+	if v["error"] != "write failed... (1014 characters)" {
+		t.Errorf("expected truncated error, got: %v", v["error"])
+	}
+
+	errPipe, errTimeout := errors.New("broken pipe"), errors.New("timeout")
+	sw := &scriptedWriter{errs: []error{errPipe, errTimeout}}
+	w = NewAggregatedWriter(sw, WithErrorPolicy(CollectAll))
+	fmt.Fprint(w, "a")
+	fmt.Fprint(w, "b")
+	if err := w.Stats().Err; err.Error() != w.Err().Error() || !errors.Is(err, errPipe) {
+		t.Errorf("expected %v, got: %v", w.Err(), err)
+	}
+}
@@ -0,0 +1,31 @@
+package demo
+
+import "io"
+
+// WithTees configures w to copy every byte accepted by the underlying writer
+// to each of dups. A failing tee is not written to again, and its error is
+// reported only by TeeErrs; it does not affect N, Err, or the other tees.
+func WithTees(dups ...io.Writer) Option {
+	return func(w *AggregatedWriter) {
+		w.tees = append(w.tees, dups...)
+		w.teeErrs = append(w.teeErrs, make([]error, len(dups))...)
+	}
+}
+
+// TeeErrs returns the error, if any, from each writer given to WithTees, in
+// the order they were given.
+func (w *AggregatedWriter) TeeErrs() []error {
+	w.lock()
+	defer w.unlock()
+	errs := make([]error, len(w.teeErrs))
+	copy(errs, w.teeErrs)
+	return errs
+}
+
+func (w *AggregatedWriter) tee(p []byte) {
+	for i, dup := range w.tees {
+		if w.teeErrs[i] == nil {
+			_, w.teeErrs[i] = writeFull(dup, p)
+		}
+	}
+}
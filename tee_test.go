@@ -0,0 +1,28 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTees(t *testing.T) {
+	expect := errors.New("audit sink down")
+	primary, healthy := &bytes.Buffer{}, &bytes.Buffer{}
+	failing := &errWriter{n: 8, err: expect}
+	w := NewAggregatedWriter(primary, WithTees(healthy, failing))
+	stringify(w, testInput)
+
+	n, err := w.Result()
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, n)
+	assertString(t, testOutput, primary.String())
+	assertString(t, testOutput, healthy.String())
+
+	errs := w.TeeErrs()
+	assertInt64(t, 2, int64(len(errs)))
+	fatalOn(t, errs[0])
+	if errs[1] != expect {
+		t.Errorf("expected %v, got: %v", expect, errs[1])
+	}
+}
@@ -0,0 +1,120 @@
+package demo
+
+import "time"
+
+// defaultThroughputWindow is the window used to measure throughput unless
+// configured with WithThroughputWindow.
+const defaultThroughputWindow = time.Second
+
+// WithThroughputWindow configures w to measure throughput over the given
+// trailing window, as reported by Throughput.
+func WithThroughputWindow(window time.Duration) Option {
+	return func(w *AggregatedWriter) { w.throughput().window = window }
+}
+
+// WithThroughputThresholds configures fn to be called with "low" when the
+// measured throughput, in bytes per second, falls below low and with "high"
+// when it rises above high. Throughput is measured on each write. An event
+// does not fire again until the opposite event has fired, so throughput
+// fluctuating between low and high does not cause repeated notifications.
+func WithThroughputThresholds(low, high float64, fn func(event string, rate float64)) Option {
+	return func(w *AggregatedWriter) {
+		t := w.throughput()
+		t.low, t.high, t.fn = low, high, fn
+	}
+}
+
+// Throughput returns the bytes per second accepted by the underlying writer
+// over the configured window, or zero if neither WithThroughputWindow nor
+// WithThroughputThresholds were given.
+func (w *AggregatedWriter) Throughput() float64 {
+	w.lock()
+	defer w.unlock()
+	return w.currentRate()
+}
+
+// ETA returns the estimated time until N reaches the total configured with
+// WithExpectedFinalN, at the current Throughput. It returns false if no
+// total was configured, throughput is not measured, or nothing has been
+// written within the window.
+func (w *AggregatedWriter) ETA() (time.Duration, bool) {
+	w.lock()
+	defer w.unlock()
+	if !w.checkN {
+		return 0, false
+	}
+	remaining := w.expectN - w.n
+	if remaining <= 0 {
+		return 0, true
+	}
+	rate := w.currentRate()
+	if rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
+
+// currentRate is Throughput for callers that hold the lock.
+func (w *AggregatedWriter) currentRate() float64 {
+	if w.rate == nil {
+		return 0
+	}
+	w.rate.expire(w.now())
+	return w.rate.rate()
+}
+
+func (w *AggregatedWriter) throughput() *throughput {
+	if w.rate == nil {
+		w.rate = &throughput{window: defaultThroughputWindow}
+	}
+	return w.rate
+}
+
+type rateSample struct {
+	at time.Time
+	n  int64
+}
+
+// throughput measures the rate of writes over a trailing window.
+type throughput struct {
+	window  time.Duration
+	samples []rateSample
+	total   int64 // bytes in samples
+
+	low, high float64
+	fn        func(event string, rate float64)
+	event     string // last event fired
+}
+
+func (t *throughput) add(now time.Time, n int) {
+	t.samples = append(t.samples, rateSample{now, int64(n)})
+	t.total += int64(n)
+	t.expire(now)
+	if t.fn == nil {
+		return
+	}
+	switch rate := t.rate(); {
+	case rate < t.low && t.event != "low":
+		t.event = "low"
+		t.fn(t.event, rate)
+	case rate > t.high && t.event != "high":
+		t.event = "high"
+		t.fn(t.event, rate)
+	}
+}
+
+// expire discards samples that fall outside the window ending at now.
+func (t *throughput) expire(now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for ; i < len(t.samples) && !t.samples[i].at.After(cutoff); i++ {
+		t.total -= t.samples[i].n
+	}
+	if i > 0 {
+		t.samples = append(t.samples[:0], t.samples[i:]...)
+	}
+}
+
+func (t *throughput) rate() float64 {
+	return float64(t.total) / t.window.Seconds()
+}
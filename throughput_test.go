@@ -0,0 +1,49 @@
+package demo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThroughput(t *testing.T) {
+	clock := newFakeClock()
+	w := NewAggregatedWriter(ioutil.Discard, WithClock(clock), WithThroughputWindow(2*time.Second))
+	w.Write(make([]byte, 100))
+	clock.Advance(time.Second)
+	w.Write(make([]byte, 300))
+	if rate := w.Throughput(); rate != 200 {
+		t.Errorf("expected 200, got: %f", rate)
+	}
+	clock.Advance(1500 * time.Millisecond)
+	if rate := w.Throughput(); rate != 150 {
+		t.Errorf("expected 150, got: %f", rate)
+	}
+}
+
+func TestThroughputThresholds(t *testing.T) {
+	var events []string
+	clock := newFakeClock()
+	w := NewAggregatedWriter(ioutil.Discard, WithClock(clock), WithThroughputThresholds(10, 100, func(event string, rate float64) {
+		events = append(events, fmt.Sprintf("%s@%g", event, rate))
+	}))
+	for _, step := range []struct {
+		after time.Duration
+		n     int
+	}{
+		{0, 50},                        // 50 B/s
+		{500 * time.Millisecond, 100},  // 150 B/s: high
+		{100 * time.Millisecond, 10},   // 160 B/s
+		{1400 * time.Millisecond, 5},   // 5 B/s: low
+		{100 * time.Millisecond, 1},    // 6 B/s
+		{100 * time.Millisecond, 20},   // 26 B/s
+		{100 * time.Millisecond, 1},    // 27 B/s
+		{1700 * time.Millisecond, 200}, // 200 B/s: high
+	} {
+		clock.Advance(step.after)
+		w.Write(make([]byte, step.n))
+	}
+	assertString(t, "high@150,low@5,high@200", strings.Join(events, ","))
+}
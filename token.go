@@ -0,0 +1,32 @@
+package demo
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// ErrUnauthorized is returned when a write does not carry the token
+// configured with WithWriteToken.
+var ErrUnauthorized = errors.New("demo: unauthorized write")
+
+// WithWriteToken configures w to accept writes only through WriteWithToken
+// with a matching token; Write fails with ErrUnauthorized. This guards
+// against accidental misuse by other code paths and is not a security
+// boundary.
+func WithWriteToken(expected string) Option {
+	return func(w *AggregatedWriter) { w.token = []byte(expected) }
+}
+
+// WriteWithToken writes p if token matches the token configured with
+// WithWriteToken, and otherwise fails with ErrUnauthorized.
+func (w *AggregatedWriter) WriteWithToken(token string, p []byte) (int, error) {
+	w.lock()
+	defer w.unlock()
+	if err := w.check(); err != nil {
+		return 0, err
+	}
+	if w.token != nil && subtle.ConstantTimeCompare([]byte(token), w.token) != 1 {
+		return 0, w.fail(ErrUnauthorized)
+	}
+	return w.writeChecked(p)
+}
@@ -0,0 +1,40 @@
+package demo
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriteToken(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWriteToken("s3cret"))
+	n, err := w.WriteWithToken("s3cret", []byte(testOutput))
+	fatalOn(t, err)
+	assertInt64(t, testOutputLength, int64(n))
+	assertString(t, testOutput, b.String())
+}
+
+func TestWriteTokenRejected(t *testing.T) {
+	for _, token := range []string{"wrong", ""} {
+		b := &bytes.Buffer{}
+		w := NewAggregatedWriter(b, WithWriteToken("s3cret"))
+		if _, err := w.WriteWithToken(token, []byte("foo")); err != ErrUnauthorized {
+			t.Errorf("expected %v, got: %v", ErrUnauthorized, err)
+		}
+		if w.Err() != ErrUnauthorized {
+			t.Errorf("expected %v, got: %v", ErrUnauthorized, w.Err())
+		}
+		assertString(t, "", b.String())
+	}
+}
+
+func TestWriteTokenDisablesWrite(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWriteToken("s3cret"))
+	if _, err := fmt.Fprint(w, "foo"); err != ErrUnauthorized {
+		t.Errorf("expected %v, got: %v", ErrUnauthorized, err)
+	}
+	assertString(t, "", b.String())
+	assertInt64(t, 0, w.N())
+}
@@ -0,0 +1,49 @@
+package demo
+
+import "io"
+
+// WithTrailer configures w to write format(stats) to the underlying writer
+// when it is closed, unless an earlier write failed. Trailer bytes are
+// reported by TrailerBytes rather than N.
+func WithTrailer(format func(stats Stats) []byte) Option {
+	return func(w *AggregatedWriter) { w.trailer = format }
+}
+
+// TrailerBytes returns the number of trailer bytes written by Close.
+func (w *AggregatedWriter) TrailerBytes() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.trailerN
+}
+
+func (w *AggregatedWriter) writeTrailer() error {
+	if w.trailer == nil || w.err != nil {
+		return nil
+	}
+	n, err := w.writeFooter(w.trailer(w.stats()))
+	w.trailerN += int64(n)
+	if err != nil {
+		w.latch(err)
+	}
+	return err
+}
+
+// writeFooter writes p, such as a trailer or MAC, to the underlying writer when w
+// is closed. Unlike write, it requires all of p to be written and does not
+// count towards N or reach observers.
+func (w *AggregatedWriter) writeFooter(p []byte) (n int, err error) {
+	if err = w.ensureWriter(); err != nil {
+		return 0, err
+	}
+	n, err = w.sinkWrite(p)
+	if n < 0 || n > len(p) {
+		n = clampN(n, 0, len(p))
+		if err == nil {
+			err = ErrBadWriteCount
+		}
+	}
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
@@ -0,0 +1,72 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestTrailer(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithTrailer(func(stats Stats) []byte {
+		return []byte(fmt.Sprintf("\n%d bytes in %d writes", stats.Bytes, stats.Writes))
+	}))
+	stringify(w, testInput)
+	fatalOn(t, w.Close())
+
+	trailer := fmt.Sprintf("\n%d bytes in %d writes", testOutputLength, 2*len(testInput)+1)
+	assertString(t, testOutput+trailer, b.String())
+	assertInt64(t, testOutputLength, w.N())
+	assertInt64(t, int64(len(trailer)), w.TrailerBytes())
+}
+
+// scriptedCloser is a scriptedWriter that counts calls to Close.
+type scriptedCloser struct {
+	scriptedWriter
+	closed int
+}
+
+func (c *scriptedCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func trailerBytes(stats Stats) []byte { return []byte("\nEOF") }
+
+func TestTrailerError(t *testing.T) {
+	expect := errors.New("disk full")
+	b := &scriptedCloser{scriptedWriter: scriptedWriter{errs: []error{nil, expect}}}
+	w := NewAggregatedWriter(b, WithTrailer(trailerBytes))
+	fmt.Fprint(w, "foo")
+	if err := w.Close(); err != expect {
+		t.Errorf("expected %v, got: %v", expect, err)
+	}
+	assertInt64(t, 1, int64(b.closed))
+	assertInt64(t, 0, w.TrailerBytes())
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+}
+
+func TestTrailerBadWrites(t *testing.T) {
+	for name, tt := range map[string]struct {
+		w      io.Writer
+		opts   []Option
+		n      int64
+		expect error
+	}{
+		"short":     {&errWriter{n: 2}, nil, 2, io.ErrShortWrite},
+		"overcount": {overcountWriter{}, nil, 4, ErrBadWriteCount},
+		"panic":     {panicWriter{}, []Option{WithPanicRecovery()}, 0, ErrWriterPanic},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := NewAggregatedWriter(tt.w, append(tt.opts, WithTrailer(trailerBytes))...)
+			if err := w.Close(); !errors.Is(err, tt.expect) {
+				t.Errorf("expected %v, got: %v", tt.expect, err)
+			}
+			assertInt64(t, tt.n, w.TrailerBytes())
+		})
+	}
+}
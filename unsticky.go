@@ -0,0 +1,35 @@
+package demo
+
+import "io"
+
+// UnstickyWriter returns a writer that writes to the underlying writer of w
+// regardless of any earlier error, for example to emit a fallback message
+// after a failure. Bytes written through it count towards N, but its errors
+// are returned only to the caller and never stored in w.
+//
+// Only the stored error is ignored: writes fail with io.ErrClosedPipe once w
+// is closed, with ErrUnauthorized if w is configured with WithWriteToken,
+// and are subject to WithLimit, WithMaxSingleWrite, WithMaxWrites and
+// WithWriteSizeValidator.
+func (w *AggregatedWriter) UnstickyWriter() io.Writer { return unstickyWriter{w} }
+
+type unstickyWriter struct{ w *AggregatedWriter }
+
+func (u unstickyWriter) Write(p []byte) (int, error) {
+	u.w.lock()
+	defer u.w.unlock()
+	if u.w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if u.w.token != nil {
+		return 0, ErrUnauthorized
+	}
+	err := u.w.guard(p)
+	if err == nil {
+		err = u.w.checkLimit(len(p))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return u.w.write(p)
+}
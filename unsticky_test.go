@@ -0,0 +1,69 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// toggleWriter fails while err is set.
+type toggleWriter struct {
+	bytes.Buffer
+	err error
+}
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestUnstickyWriter(t *testing.T) {
+	expect := errors.New("broken")
+	b := &toggleWriter{}
+	w := NewAggregatedWriter(b)
+	fmt.Fprint(w, "foo")
+	b.err = expect
+	fmt.Fprint(w, "bar")
+	b.err = nil
+	fmt.Fprint(w, "baz")
+
+	fmt.Fprint(w.UnstickyWriter(), "fallback")
+	assertString(t, "foofallback", b.String())
+	assertInt64(t, 11, w.N())
+	if w.Err() != expect {
+		t.Errorf("expected %v, got: %v", expect, w.Err())
+	}
+}
+
+func TestUnstickyWriterChecks(t *testing.T) {
+	for name, tt := range map[string]struct {
+		opts   []Option
+		close  bool
+		expect error
+	}{
+		"closed": {nil, true, io.ErrClosedPipe},
+		"token":  {[]Option{WithWriteToken("s3cret")}, false, ErrUnauthorized},
+		"limit":  {[]Option{WithLimit(4)}, false, ErrLimitExceeded},
+		"single": {[]Option{WithMaxSingleWrite(4)}, false, ErrWriteTooLarge},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			w := NewAggregatedWriter(b, tt.opts...)
+			if tt.close {
+				fatalOn(t, w.Close())
+			}
+			if _, err := fmt.Fprint(w.UnstickyWriter(), "fallback"); !errors.Is(err, tt.expect) {
+				t.Errorf("expected %v, got: %v", tt.expect, err)
+			}
+			assertString(t, "", b.String())
+			assertInt64(t, 0, w.N())
+			if !tt.close {
+				fatalOn(t, w.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package demo
+
+// WithWrap configures w to write prefix before and suffix after the bytes
+// given to each call to Write. It applies per call, not per line. N includes
+// the prefix and suffix bytes, while Write reports only the bytes of its
+// argument that were written.
+func WithWrap(prefix, suffix []byte) Option {
+	return func(w *AggregatedWriter) { w.prefix, w.suffix = prefix, suffix }
+}
+
+// overhead returns the number of bytes added to each write by WithWrap.
+func (w *AggregatedWriter) overhead() int { return len(w.prefix) + len(w.suffix) }
+
+func (w *AggregatedWriter) writeWrapped(p []byte) (n int, err error) {
+	w.wrapBuf = append(append(append(w.wrapBuf[:0], w.prefix...), p...), w.suffix...)
+	n, err = w.writeOut(w.wrapBuf)
+	return clampN(n-len(w.prefix), 0, len(p)), err
+}
+
+func clampN(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
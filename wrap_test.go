@@ -0,0 +1,37 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWrap([]byte("> "), []byte("\n")))
+	n, err := fmt.Fprint(w, "foo")
+	fatalOn(t, err)
+	assertInt64(t, 3, int64(n))
+	fmt.Fprint(w, "bar")
+	assertString(t, "> foo\n> bar\n", b.String())
+	assertInt64(t, 12, w.N())
+}
+
+func TestWrapEmpty(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewAggregatedWriter(b, WithWrap(nil, nil))
+	stringify(w, testInput)
+	assertString(t, testOutput, b.String())
+	assertInt64(t, testOutputLength, w.N())
+}
+
+func TestWrapPartial(t *testing.T) {
+	w := NewAggregatedWriter(&errWriter{n: 4, err: errors.New("broken")}, WithWrap([]byte("> "), []byte("\n")))
+	n, err := fmt.Fprint(w, "foo")
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	assertInt64(t, 2, int64(n))
+	assertInt64(t, 4, w.N())
+}
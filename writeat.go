@@ -0,0 +1,93 @@
+package demo
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrWriteAtUnsupported is returned by WriteAt if the underlying writer
+	// does not implement io.WriterAt.
+	ErrWriteAtUnsupported = errors.New("demo: underlying writer does not support WriteAt")
+
+	// ErrWriteAtEncrypted is returned by WriteAt if w is configured with
+	// WithStreamCipher, which can only encrypt a sequential stream.
+	ErrWriteAtEncrypted = errors.New("demo: WriteAt cannot be used with a stream cipher")
+)
+
+// WriteAt implements io.WriterAt by writing p at offset off of the
+// underlying writer. Bytes written count towards N, which may therefore
+// exceed the size of the destination when ranges overlap; see
+// HighWaterMark. They are not passed to observers such as hashes or line
+// callbacks, which expect a sequential stream.
+//
+// Like Write, WriteAt fails with ErrUnauthorized if w is configured with
+// WithWriteToken, and is subject to WithLimit, WithMaxSingleWrite,
+// WithMaxWrites and WithWriteSizeValidator. A write that would exceed a
+// truncating limit is rejected rather than truncated.
+//
+// WriteAt fails with ErrWriteAtUnsupported, without storing the error, if
+// the underlying writer does not implement io.WriterAt, and with
+// ErrWriteAtEncrypted if w is configured with WithStreamCipher.
+func (w *AggregatedWriter) WriteAt(p []byte, off int64) (n int, err error) {
+	w.lock()
+	defer w.unlock()
+	if err = w.check(); err != nil {
+		return 0, err
+	}
+	if w.cipher != nil {
+		return 0, ErrWriteAtEncrypted
+	}
+	if err = w.ensureWriter(); err != nil {
+		return 0, w.fail(err)
+	}
+	wa, ok := w.w.(io.WriterAt)
+	if !ok {
+		return 0, ErrWriteAtUnsupported
+	}
+	if w.token != nil {
+		return 0, w.fail(ErrUnauthorized)
+	}
+	if err = w.guard(p); err == nil {
+		err = w.checkLimit(len(p))
+	}
+	if err != nil {
+		return 0, w.fail(err)
+	}
+	w.touched = true
+	n, err = wa.WriteAt(p, off)
+	if err != nil && w.mapErr != nil {
+		err = w.mapErr(err)
+	}
+	if n < 0 || n > len(p) {
+		n = clampN(n, 0, len(p))
+		if err == nil {
+			err = ErrBadWriteCount
+		}
+	}
+	w.n += int64(n)
+	w.unflushed += int64(n)
+	w.view.addBytes(int64(n))
+	if end := off + int64(n); end > w.hwm {
+		w.hwm = end
+	}
+	if err != nil {
+		w.errors++
+		w.view.addError()
+		w.fail(err)
+	} else {
+		w.writes++
+		w.view.addWrite()
+		w.observeWrite(int64(n))
+	}
+	return
+}
+
+// HighWaterMark returns the highest offset written by WriteAt, which is the
+// size of the destination if it was empty. Unlike N, overwritten ranges are
+// not counted twice.
+func (w *AggregatedWriter) HighWaterMark() int64 {
+	w.lock()
+	defer w.unlock()
+	return w.hwm
+}
@@ -0,0 +1,125 @@
+package demo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+	f, err := os.Create(filepath.Join(dir, "pages"))
+	fatalOn(t, err)
+
+	w := NewAggregatedWriter(f)
+	for _, tt := range []struct {
+		p   string
+		off int64
+	}{
+		{"aaaa", 0},
+		{"bbbb", 8},
+		{"cccc", 2},
+		{"dd", 0},
+	} {
+		_, err := w.WriteAt([]byte(tt.p), tt.off)
+		fatalOn(t, err)
+	}
+	fatalOn(t, w.Close())
+
+	assertInt64(t, 14, w.N())
+	assertInt64(t, 12, w.HighWaterMark())
+	assertInt64(t, 4, w.WriteCount())
+	b, err := ioutil.ReadFile(f.Name())
+	fatalOn(t, err)
+	assertString(t, "ddcccc\x00\x00bbbb", string(b))
+}
+
+func TestWriteAtUnsupported(t *testing.T) {
+	w := NewAggregatedWriter(&bytes.Buffer{})
+	if _, err := w.WriteAt([]byte("foo"), 0); !errors.Is(err, ErrWriteAtUnsupported) {
+		t.Fatalf("expected %v, got: %v", ErrWriteAtUnsupported, err)
+	}
+	fatalOn(t, w.Err())
+	assertInt64(t, 0, w.N())
+}
+
+func TestWriteAtGuards(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, tt := range map[string]struct {
+		opt   Option
+		prior string
+		err   error
+	}{
+		"token":    {WithWriteToken("secret"), "", ErrUnauthorized},
+		"limit":    {WithLimit(3), "", ErrLimitExceeded},
+		"truncate": {WithTruncatingLimit(3), "", ErrLimitExceeded},
+		"single":   {WithMaxSingleWrite(2), "", ErrWriteTooLarge},
+		"writes":   {WithMaxWrites(1), "a", ErrTooManyWrites},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Create(filepath.Join(dir, name))
+			fatalOn(t, err)
+			w := NewAggregatedWriter(f, tt.opt, WithContinueOnError())
+			if tt.prior != "" {
+				_, err := w.WriteAt([]byte(tt.prior), 0)
+				fatalOn(t, err)
+			}
+			if _, err := w.WriteAt([]byte("bcde"), 1); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got: %v", tt.err, err)
+			}
+			w.Close()
+			b, err := ioutil.ReadFile(f.Name())
+			fatalOn(t, err)
+			assertString(t, tt.prior, string(b))
+		})
+	}
+}
+
+func TestWriteAtTokenAndLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+	f, err := os.Create(filepath.Join(dir, "guarded"))
+	fatalOn(t, err)
+
+	w := NewAggregatedWriter(f, WithWriteToken("secret"), WithLimit(3), WithContinueOnError())
+	if _, err := w.WriteAt([]byte("ab"), 0); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected %v, got: %v", ErrUnauthorized, err)
+	}
+	_, err = w.WriteWithToken("secret", []byte("ab"))
+	fatalOn(t, err)
+	if _, err := w.WriteAt([]byte("cd"), 2); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected %v, got: %v", ErrUnauthorized, err)
+	}
+	w.Close()
+	assertInt64(t, 2, w.N())
+	b, err := ioutil.ReadFile(f.Name())
+	fatalOn(t, err)
+	assertString(t, "ab", string(b))
+}
+
+func TestWriteAtLazy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "demo")
+	fatalOn(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "lazy")
+
+	w := NewLazyAggregatedWriter(func() (io.Writer, error) { return os.Create(name) })
+	_, err = w.WriteAt([]byte("bar"), 3)
+	fatalOn(t, err)
+	_, err = w.WriteAt([]byte("foo"), 0)
+	fatalOn(t, err)
+	fatalOn(t, w.Close())
+	b, err := ioutil.ReadFile(name)
+	fatalOn(t, err)
+	assertString(t, "foobar", string(b))
+}